@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"backend/models"
+	"backend/service"
+)
+
+// chapterPathIDs extracts the novel ID and chapter ID from /novels/{id}/chapters/id/{chapterId}/...
+func chapterPathIDs(r *http.Request) (string, string, bool) {
+	path := strings.TrimPrefix(r.URL.Path, "/novels/")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < 5 {
+		return "", "", false
+	}
+	return pathParts[0], pathParts[3], true
+}
+
+// chapterVersionErrorStatus returns the HTTP status code for an error of the chapter versions
+func chapterVersionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidVersion):
+		return http.StatusBadRequest
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// getChapterVersions handles GET /novels/{id}/chapters/id/{chapterId}/versions
+func getChapterVersions(w http.ResponseWriter, r *http.Request) {
+	novelID, chapterID, ok := chapterPathIDs(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	versions, err := service.GetNovelService().GetChapterVersions(novelID, chapterID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve chapter versions: "+err.Error(), chapterVersionErrorStatus(err))
+		return
+	}
+
+	// If no versions found, return an empty array
+	if len(versions) == 0 {
+		writeJSON(w, []models.ChapterVersion{}, http.StatusOK)
+		return
+	}
+
+	writeJSON(w, versions, http.StatusOK)
+}
+
+// setActiveChapterVersion handles PUT /novels/{id}/chapters/id/{chapterId}/active_version
+func setActiveChapterVersion(w http.ResponseWriter, r *http.Request) {
+	novelID, chapterID, ok := chapterPathIDs(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	var request models.ActiveVersionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	chapter, err := service.GetNovelService().SetActiveChapterVersion(novelID, chapterID, request.Version)
+	if err != nil {
+		http.Error(w, "Failed to set active chapter version: "+err.Error(), chapterVersionErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, chapter, http.StatusOK)
+}
+
+// diffChapterVersions handles GET /novels/{id}/chapters/id/{chapterId}/diff?from={version}&to={version}
+func diffChapterVersions(w http.ResponseWriter, r *http.Request) {
+	novelID, chapterID, ok := chapterPathIDs(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, "Invalid from version", http.StatusBadRequest)
+		return
+	}
+
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, "Invalid to version", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := service.GetNovelService().DiffChapterVersions(novelID, chapterID, from, to)
+	if err != nil {
+		http.Error(w, "Failed to diff chapter versions: "+err.Error(), chapterVersionErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, diff, http.StatusOK)
+}
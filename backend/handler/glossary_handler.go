@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"backend/models"
+	"backend/service"
+)
+
+// getNovelGlossary handles GET /novels/{id}/glossary?conflicts={true|false}
+func getNovelGlossary(w http.ResponseWriter, r *http.Request) {
+	// Extract novel ID from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/novels/")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < 2 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	novelID := pathParts[0]
+
+	var terms []*models.GlossaryTerm
+	var err error
+	if r.URL.Query().Get("conflicts") == "true" {
+		terms, err = service.GetGlossaryService().GetGlossaryConflicts(novelID)
+	} else {
+		terms, err = service.GetGlossaryService().GetGlossary(novelID)
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve glossary: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// If no terms found, return an empty array
+	if len(terms) == 0 {
+		writeJSON(w, []models.GlossaryTerm{}, http.StatusOK)
+		return
+	}
+
+	writeJSON(w, terms, http.StatusOK)
+}
+
+// createGlossaryTerm handles POST /novels/{id}/glossary
+func createGlossaryTerm(w http.ResponseWriter, r *http.Request) {
+	// Extract novel ID from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/novels/")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < 2 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	var term models.GlossaryTerm
+	if err := json.NewDecoder(r.Body).Decode(&term); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	term.NovelID = pathParts[0]
+
+	createdTerm, err := service.GetGlossaryService().CreateGlossaryTerm(&term)
+	if err != nil {
+		http.Error(w, "Failed to create glossary term: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, createdTerm, http.StatusCreated)
+}
+
+// updateGlossaryTerm handles PUT /novels/{id}/glossary/{termId}
+func updateGlossaryTerm(w http.ResponseWriter, r *http.Request) {
+	// Extract novel ID and term ID from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/novels/")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < 3 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	var request models.GlossaryTermUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	request.NovelID = pathParts[0]
+	request.ID = pathParts[2]
+
+	updatedTerm, err := service.GetGlossaryService().UpdateGlossaryTerm(&request)
+	if err != nil {
+		http.Error(w, "Failed to update glossary term: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, updatedTerm, http.StatusOK)
+}
+
+// deleteGlossaryTerm handles DELETE /novels/{id}/glossary/{termId}
+func deleteGlossaryTerm(w http.ResponseWriter, r *http.Request) {
+	// Extract novel ID and term ID from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/novels/")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < 3 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	novelID := pathParts[0]
+	termID := pathParts[2]
+
+	err := service.GetGlossaryService().DeleteGlossaryTerm(novelID, termID)
+	if err != nil {
+		http.Error(w, "Failed to delete glossary term: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"backend/repo"
 )
@@ -14,24 +15,50 @@ func RegisterHandlers(mux *http.ServeMux) {
 
 	// Stats endpoint
 	mux.HandleFunc("GET /stats/novels", getNovelStats)
+	mux.HandleFunc("GET /stats/usage", getUsageStats)
 
 	// Sources CRUD APIs
 	mux.HandleFunc("GET /sources", getAllSources)
+	mux.HandleFunc("POST /sources/resolve", resolveSource)
 
 	// Novels CRUD APIs
 	mux.HandleFunc("GET /novels", getNovelsUsingFilter)
 	mux.HandleFunc("GET /search/novels/{query}", searchNovel)
 	mux.HandleFunc("GET /novels/{id}", getNovelByID)
 	mux.HandleFunc("DELETE /novels/{id}", deleteNovel)
+	mux.HandleFunc("PUT /novels/{id}/language", setNovelLanguage)
+	mux.HandleFunc("PUT /novels/{id}/style", setNovelStyle)
 	mux.HandleFunc("GET /novels/{id}/chapters", getNovelChapters)
 	mux.HandleFunc("GET /novels/{id}/chapters/num/{chapterNumber}", getNovelChapterByNumber)
+	mux.HandleFunc("GET /novels/{id}/chapters/num/{chapterNumber}/bilingual", getBilingualChapter)
+	mux.HandleFunc("GET /novels/{id}/chapters/num/{chapterNumber}/url", getChapterURL)
 	mux.HandleFunc("DELETE /novels/{id}/chapters/{chapterId}", deleteChapter)
 
+	// Chapter version APIs, addressing chapters with id/ so that they do not clash with num/
+	mux.HandleFunc("GET /novels/{id}/chapters/id/{chapterId}/versions", getChapterVersions)
+	mux.HandleFunc("PUT /novels/{id}/chapters/id/{chapterId}/active_version", setActiveChapterVersion)
+	mux.HandleFunc("GET /novels/{id}/chapters/id/{chapterId}/diff", diffChapterVersions)
+
+	// Glossary CRUD APIs
+	mux.HandleFunc("GET /novels/{id}/glossary", getNovelGlossary)
+	mux.HandleFunc("POST /novels/{id}/glossary", createGlossaryTerm)
+	mux.HandleFunc("PUT /novels/{id}/glossary/{termId}", updateGlossaryTerm)
+	mux.HandleFunc("DELETE /novels/{id}/glossary/{termId}", deleteGlossaryTerm)
+
+	// Style preset CRUD APIs
+	mux.HandleFunc("GET /styles", getStylePresets)
+	mux.HandleFunc("POST /styles", createStylePreset)
+	mux.HandleFunc("PUT /styles/{name}", updateStylePreset)
+	mux.HandleFunc("DELETE /styles/{name}", deleteStylePreset)
+
 	// Translation APIs
 	mux.HandleFunc("POST /novels/translate", extractNovelDetails)
 	mux.HandleFunc("POST /novels/translate/chapter", translateNovelChapter)
 	mux.HandleFunc("POST /novels/translate/first_chapter", translateFirstChapter)
 	mux.HandleFunc("POST /novels/refresh", refreshNovel)
+	mux.HandleFunc("POST /novels/{id}/chapters/{chapterId}/retranslate", retranslateChapter)
+	mux.HandleFunc("POST /novels/{id}/toc", updateTableOfContents)
+	mux.HandleFunc("GET /prompts", getPromptTemplates)
 }
 
 // healthCheckHandler provides a simple health check endpoint
@@ -54,6 +81,16 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, resp, http.StatusOK)
 }
 
+// includes reports whether the comma-separated include query parameter asks for the field, e.g. ?include=original
+func includes(r *http.Request, field string) bool {
+	for _, included := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(included) == field {
+			return true
+		}
+	}
+	return false
+}
+
 // writeJSON is a helper function to write JSON responses
 func writeJSON(w http.ResponseWriter, data interface{}, status int) {
 	w.Header().Set("Content-Type", "application/json")
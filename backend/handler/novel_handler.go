@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"backend/models"
+	"backend/provider/sources"
 	"backend/service"
 )
 
@@ -119,7 +122,61 @@ func deleteNovel(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// getNovelChapters handles GET /novels/{id}/chapters
+// setNovelLanguage handles PUT /novels/{id}/language
+func setNovelLanguage(w http.ResponseWriter, r *http.Request) {
+	// Extract novel ID from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/novels/")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < 2 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	var request models.NovelLanguageRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	novel, err := service.GetNovelService().SetNovelLanguage(pathParts[0], request.TargetLanguage)
+	if err != nil {
+		http.Error(w, "Failed to update novel language: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, novel, http.StatusOK)
+}
+
+// setNovelStyle handles PUT /novels/{id}/style
+func setNovelStyle(w http.ResponseWriter, r *http.Request) {
+	// Extract novel ID from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/novels/")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < 2 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	var request models.NovelStyleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	novel, err := service.GetNovelService().SetNovelStyle(pathParts[0], request.Style)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrUnknownStyle) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, "Failed to update novel style: "+err.Error(), status)
+		return
+	}
+
+	writeJSON(w, novel, http.StatusOK)
+}
+
+// getNovelChapters handles GET /novels/{id}/chapters?lang={language}&include=original,untranslated
 func getNovelChapters(w http.ResponseWriter, r *http.Request) {
 	// Extract novel ID from URL path
 	path := strings.TrimPrefix(r.URL.Path, "/novels/")
@@ -131,7 +188,7 @@ func getNovelChapters(w http.ResponseWriter, r *http.Request) {
 
 	novelID := pathParts[0]
 
-	chapters, err := service.GetNovelService().GetNovelChapters(novelID)
+	chapters, err := service.GetNovelService().GetNovelChapters(novelID, r.URL.Query().Get("lang"), includes(r, "untranslated"))
 	if err != nil {
 		http.Error(w, "Failed to retrieve chapters: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -143,10 +200,17 @@ func getNovelChapters(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if includes(r, "original") {
+		if err = service.GetNovelService().IncludeOriginal(chapters...); err != nil {
+			http.Error(w, "Failed to retrieve chapter originals: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	writeJSON(w, chapters, http.StatusOK)
 }
 
-// getNovelChapterByNumber handles GET /novels/{id}/chapters/num/{chapterNumber}
+// getNovelChapterByNumber handles GET /novels/{id}/chapters/num/{chapterNumber}?lang={language}&include=original
 func getNovelChapterByNumber(w http.ResponseWriter, r *http.Request) {
 	// Extract novel ID and chapter number from URL path
 	path := strings.TrimPrefix(r.URL.Path, "/novels/")
@@ -163,15 +227,78 @@ func getNovelChapterByNumber(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	chapter, err := service.GetNovelService().GetChapterByNumber(novelID, chapterNumber)
+	chapter, err := service.GetNovelService().GetChapterByNumber(novelID, chapterNumber, r.URL.Query().Get("lang"))
 	if err != nil {
 		http.Error(w, "Failed to retrieve chapter: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if includes(r, "original") {
+		if err = service.GetNovelService().IncludeOriginal(chapter); err != nil {
+			http.Error(w, "Failed to retrieve chapter original: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	writeJSON(w, chapter, http.StatusOK)
 }
 
+// getBilingualChapter handles GET /novels/{id}/chapters/num/{chapterNumber}/bilingual?lang={language}
+func getBilingualChapter(w http.ResponseWriter, r *http.Request) {
+	// Extract novel ID and chapter number from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/novels/")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < 5 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	novelID := pathParts[0]
+	chapterNumber, err := strconv.Atoi(pathParts[3])
+	if err != nil {
+		http.Error(w, "Invalid chapter number", http.StatusBadRequest)
+		return
+	}
+
+	chapter, err := service.GetNovelService().GetBilingualChapter(novelID, chapterNumber, r.URL.Query().Get("lang"))
+	if err != nil {
+		http.Error(w, "Failed to retrieve bilingual chapter: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, chapter, http.StatusOK)
+}
+
+// getChapterURL handles GET /novels/{id}/chapters/num/{chapterNumber}/url
+func getChapterURL(w http.ResponseWriter, r *http.Request) {
+	// Extract novel ID and chapter number from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/novels/")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < 5 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	novelID := pathParts[0]
+	chapterNumber, err := strconv.Atoi(pathParts[3])
+	if err != nil || chapterNumber < 1 {
+		http.Error(w, "Invalid chapter number", http.StatusBadRequest)
+		return
+	}
+
+	response, err := service.GetNovelService().GetChapterURL(novelID, chapterNumber)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrUnknownChapterURL) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, "Failed to find chapter URL: "+err.Error(), status)
+		return
+	}
+
+	writeJSON(w, response, http.StatusOK)
+}
+
 // deleteChapter handles DELETE /novels/{novelId}/chapters/{chapterId}
 func deleteChapter(w http.ResponseWriter, r *http.Request) {
 	// Extract novel ID and chapter ID from URL path
@@ -204,3 +331,24 @@ func getAllSources(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, sources, http.StatusOK)
 }
+
+// resolveSource handles POST /sources/resolve
+func resolveSource(w http.ResponseWriter, r *http.Request) {
+	var request models.SourceResolveRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resolution, err := service.GetNovelService().ResolveSource(request.URL)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, sources.ErrUnknownSource) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, "Failed to resolve source: "+err.Error(), status)
+		return
+	}
+
+	writeJSON(w, resolution, http.StatusOK)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
 	"backend/service"
 )
@@ -17,3 +18,24 @@ func getNovelStats(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, stats, http.StatusOK)
 }
+
+// getUsageStats handles GET /stats/usage?days={days}&novel_id={novelId}
+func getUsageStats(w http.ResponseWriter, r *http.Request) {
+	days := 30
+	if d := r.URL.Query().Get("days"); d != "" {
+		dInt, err := strconv.Atoi(d)
+		if err != nil || dInt <= 0 {
+			http.Error(w, "Invalid number of days", http.StatusBadRequest)
+			return
+		}
+		days = dInt
+	}
+
+	stats, err := service.GetStatsService().GetUsageStats(days, r.URL.Query().Get("novel_id"))
+	if err != nil {
+		http.Error(w, "Failed to get usage stats: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, stats, http.StatusOK)
+}
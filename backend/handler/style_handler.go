@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"backend/models"
+	"backend/service"
+)
+
+// styleErrorStatus returns the HTTP status code for an error of the style service
+func styleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidStyle):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrBuiltInStyle):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrUnknownStyle):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrStyleExists):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// getStylePresets handles GET /styles
+func getStylePresets(w http.ResponseWriter, r *http.Request) {
+	presets, err := service.GetStyleService().GetStylePresets()
+	if err != nil {
+		http.Error(w, "Failed to retrieve style presets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, presets, http.StatusOK)
+}
+
+// createStylePreset handles POST /styles
+func createStylePreset(w http.ResponseWriter, r *http.Request) {
+	var preset models.StylePreset
+	if err := json.NewDecoder(r.Body).Decode(&preset); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdPreset, err := service.GetStyleService().CreateStylePreset(&preset)
+	if err != nil {
+		http.Error(w, "Failed to create style preset: "+err.Error(), styleErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, createdPreset, http.StatusCreated)
+}
+
+// updateStylePreset handles PUT /styles/{name}
+func updateStylePreset(w http.ResponseWriter, r *http.Request) {
+	// Extract preset name from URL path
+	name := strings.TrimPrefix(r.URL.Path, "/styles/")
+	if name == "" {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	var preset models.StylePreset
+	if err := json.NewDecoder(r.Body).Decode(&preset); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	preset.Name = name
+
+	updatedPreset, err := service.GetStyleService().UpdateStylePreset(&preset)
+	if err != nil {
+		http.Error(w, "Failed to update style preset: "+err.Error(), styleErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, updatedPreset, http.StatusOK)
+}
+
+// deleteStylePreset handles DELETE /styles/{name}
+func deleteStylePreset(w http.ResponseWriter, r *http.Request) {
+	// Extract preset name from URL path
+	name := strings.TrimPrefix(r.URL.Path, "/styles/")
+	if name == "" {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	err := service.GetStyleService().DeleteStylePreset(name)
+	if err != nil {
+		http.Error(w, "Failed to delete style preset: "+err.Error(), styleErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
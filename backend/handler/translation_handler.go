@@ -1,10 +1,16 @@
 package handler
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 
 	"backend/models"
+	"backend/provider/llm"
+	"backend/provider/sources"
 	"backend/service"
 )
 
@@ -20,7 +26,8 @@ func extractNovelDetails(w http.ResponseWriter, r *http.Request) {
 
 	createdNovel, err := service.GetTranslationService().ExtractNovelDetails(r.Context(), &request)
 	if err != nil {
-		http.Error(w, "Failed to extract novel details: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to extract novel details: "+err.Error(), translationErrorStatus(err))
+		return
 	}
 
 	// Send the response
@@ -39,7 +46,7 @@ func translateNovelChapter(w http.ResponseWriter, r *http.Request) {
 	// Call the translation service to translate the chapter
 	translatedChapter, err := service.GetTranslationService().TranslateChapter(r.Context(), &request)
 	if err != nil {
-		http.Error(w, "Failed to translate chapter: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to translate chapter: "+err.Error(), translationErrorStatus(err))
 		return
 	}
 
@@ -59,7 +66,7 @@ func translateFirstChapter(w http.ResponseWriter, r *http.Request) {
 	// Call the translation service to translate the first chapter
 	response, err := service.GetTranslationService().TranslateFirstChapter(r.Context(), &request)
 	if err != nil {
-		http.Error(w, "Failed to translate first chapter: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to translate first chapter: "+err.Error(), translationErrorStatus(err))
 		return
 	}
 
@@ -77,9 +84,91 @@ func refreshNovel(w http.ResponseWriter, r *http.Request) {
 
 	response, err := service.GetTranslationService().RefreshNovel(r.Context(), &novelRefreshRequest)
 	if err != nil {
-		http.Error(w, "Failed to refresh novel: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to refresh novel: "+err.Error(), translationErrorStatus(err))
 		return
 	}
 
 	writeJSON(w, response, http.StatusOK)
 }
+
+// translationErrorStatus returns the HTTP status code for an error of the translation service
+func translationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrBudgetExceeded):
+		return http.StatusPaymentRequired
+	case errors.Is(err, llm.ErrUnknownProvider), errors.Is(err, service.ErrUnknownStyle), errors.Is(err, sources.ErrUnknownSource), errors.Is(err, sources.ErrNoTableOfContents), errors.Is(err, service.ErrInvalidNovelURL), errors.Is(err, service.ErrChapterURLMismatch):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrUnknownChapterURL), errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, llm.ErrContentRefused):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, llm.ErrInvalidResponse), errors.Is(err, llm.ErrTruncatedResponse):
+		return http.StatusBadGateway
+	case errors.Is(err, llm.ErrProviderUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// retranslateChapter handles POST /novels/{id}/chapters/{chapterId}/retranslate
+func retranslateChapter(w http.ResponseWriter, r *http.Request) {
+	// Extract novel ID and chapter ID from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/novels/")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < 4 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+	novelID, chapterID := pathParts[0], pathParts[2]
+
+	// Parse the incoming request, whose body is optional
+	var request models.ChapterRetranslationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	request.NovelID = novelID
+	request.ChapterID = chapterID
+
+	// Call the translation service to translate the chapter again
+	chapter, err := service.GetTranslationService().RetranslateChapter(r.Context(), &request)
+	if err != nil {
+		http.Error(w, "Failed to retranslate chapter: "+err.Error(), translationErrorStatus(err))
+		return
+	}
+
+	// Send the response
+	writeJSON(w, chapter, http.StatusOK)
+}
+
+// updateTableOfContents handles POST /novels/{id}/toc
+func updateTableOfContents(w http.ResponseWriter, r *http.Request) {
+	// Extract novel ID from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/novels/")
+	pathParts := strings.Split(path, "/")
+	if len(pathParts) < 2 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	// Parse the incoming request, whose body is optional
+	var request models.TableOfContentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	request.NovelID = pathParts[0]
+
+	entries, err := service.GetTranslationService().UpdateTableOfContents(&request)
+	if err != nil {
+		http.Error(w, "Failed to update table of contents: "+err.Error(), translationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, entries, http.StatusOK)
+}
+
+// getPromptTemplates handles GET /prompts
+func getPromptTemplates(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, service.GetTranslationService().GetPromptTemplates(), http.StatusOK)
+}
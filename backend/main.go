@@ -12,13 +12,30 @@ import (
 	"time"
 
 	"backend/handler"
-	_ "backend/provider/llm"
+	"backend/provider/llm"
+	"backend/provider/sources"
 	_ "backend/provider/webscraper"
-	_ "backend/repo"
-	_ "backend/service"
+	"backend/repo"
+	"backend/service"
 )
 
 func main() {
+	// Load the prompt templates before serving any translation
+	if err := llm.LoadPromptTemplates(); err != nil {
+		log.Fatalf("❌ Failed to load prompt templates: %v", err)
+	}
+
+	// Register the sources configured in SOURCES_CONFIG next to the built-in ones
+	if err := sources.LoadConfiguredSources(); err != nil {
+		log.Fatalf("❌ Failed to load sources from SOURCES_CONFIG: %v", err)
+	}
+
+	// Open the database and create the services on it
+	if err := repo.OpenDatabase(); err != nil {
+		log.Fatalf("❌ Failed to initialize database: %v", err)
+	}
+	service.Init(repo.GetRepo())
+
 	// Create a new HTTP server
 	server := setupServer()
 
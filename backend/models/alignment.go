@@ -0,0 +1,74 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// AlignedParagraph pairs paragraphs of the original with their translation.
+// OriginalIDs are the indexes of the paragraphs in the original text of the chapter; they are empty for a translated
+// paragraph without counterpart, as Translated is empty for original paragraphs left out of the translation, like
+// the navigation of the page.
+type AlignedParagraph struct {
+	OriginalIDs []int  `json:"original_ids"`
+	Original    string `json:"original"`
+	Translated  string `json:"translated"`
+}
+
+// ChapterAlignment is the paragraph alignment of one version of a chapter with its original
+type ChapterAlignment struct {
+	ChapterID   string              `json:"chapter_id"`
+	NovelID     string              `json:"novel_id"`
+	Version     int                 `json:"version"`
+	Paragraphs  []*AlignedParagraph `json:"paragraphs"`
+	DateAligned int64               `json:"date_aligned"`
+}
+
+// BilingualChapter is a chapter with its original, paragraph by paragraph
+type BilingualChapter struct {
+	ChapterID     string              `json:"chapter_id"`
+	NovelID       string              `json:"novel_id"`
+	Number        int                 `json:"number"`
+	Language      string              `json:"language"`
+	Version       int                 `json:"version"`
+	Title         string              `json:"title"`
+	OriginalTitle string              `json:"original_title,omitempty"`
+	Paragraphs    []*AlignedParagraph `json:"paragraphs"`
+}
+
+// ScanChapterAlignment scans a chapter alignment from a SQL row
+func ScanChapterAlignment(row *sql.Row) (*ChapterAlignment, error) {
+	var alignment ChapterAlignment
+	var paragraphsJSON string
+
+	err := row.Scan(
+		&alignment.ChapterID,
+		&alignment.NovelID,
+		&alignment.Version,
+		&paragraphsJSON,
+		&alignment.DateAligned,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal([]byte(paragraphsJSON), &alignment.Paragraphs); err != nil {
+		return nil, err
+	}
+
+	return &alignment, nil
+}
+
+// AlignedParagraphsToJSON converts the paragraphs of an alignment to a JSON string for storage
+func AlignedParagraphsToJSON(paragraphs []*AlignedParagraph) (string, error) {
+	if paragraphs == nil {
+		return "[]", nil
+	}
+
+	bytes, err := json.Marshal(paragraphs)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
@@ -11,41 +11,102 @@ type NovelListResponse struct {
 
 // NovelDetails represents details about a novel extracted and translated from a source
 type NovelDetails struct {
-	NovelTitleOriginal        string   `json:"novel_title_original"`
-	NovelTitleTranslated      string   `json:"novel_title_translated"`
-	NovelSummaryTranslated    string   `json:"novel_summary_translated"`
-	NovelAuthorNameTranslated string   `json:"novel_author_name_translated,omitempty"`
-	PossibleNovelGenres       []string `json:"possible_novel_genres,omitempty"`
-	NumberOfChapters          int      `json:"number_of_chapters"`
-	Status                    string   `json:"status,omitempty"`
+	NovelTitleOriginal        string   `json:"novel_title_original" description:"Original title in the source language"`
+	NovelTitleTranslated      string   `json:"novel_title_translated" description:"Translated title in the target language"`
+	NovelSummaryTranslated    string   `json:"novel_summary_translated" description:"Translated summary in the target language in HTML format with paragraph tags"`
+	NovelAuthorNameTranslated string   `json:"novel_author_name_translated,omitempty" description:"Translated name of the author"`
+	PossibleNovelGenres       []string `json:"possible_novel_genres,omitempty" description:"List of possible genres for the novel"`
+	NumberOfChapters          int      `json:"number_of_chapters" description:"Total number of chapters in original as integer"`
+	Status                    string   `json:"status,omitempty" description:"Status of the novel: Ongoing, Completed or Unknown"`
 }
 
 // TranslatedChapter represents a chapter that has been translated
 type TranslatedChapter struct {
-	TranslatedChapterTitle    string   `json:"translated_chapter_title"`
-	OriginalChapterTitle      string   `json:"original_chapter_title,omitempty"`
-	TranslatedChapterContents string   `json:"translated_chapter_contents"`
-	PossibleNewGenres         []string `json:"possible_new_genres,omitempty"`
+	TranslatedChapterTitle    string                   `json:"translated_chapter_title" description:"The translated title of the chapter"`
+	OriginalChapterTitle      string                   `json:"original_chapter_title,omitempty" description:"The original title in the source language"`
+	TranslatedChapterContents string                   `json:"translated_chapter_contents" description:"The translated content of the full chapter in HTML format with paragraph tags"`
+	PossibleNewGenres         []string                 `json:"possible_new_genres,omitempty" description:"Standard genres detected that are not already known, empty if none"`
+	NewGlossaryTerms          []*ExtractedGlossaryTerm `json:"new_glossary_terms,omitempty" description:"Proper nouns of this chapter that are not in the glossary, with the translation chosen, empty if none"`
+	UpdatedStorySummary       string                   `json:"updated_story_summary,omitempty" description:"Summary of the story so far including this chapter, under 400 words"`
+
+	// Provider and Attempts are filled in by the fallback client and are never part of the LLM response
+	Provider string `json:"-"`
+	Attempts int    `json:"-"`
+}
+
+// ExtractedGlossaryTerm represents a proper noun the LLM introduced while translating a chapter
+type ExtractedGlossaryTerm struct {
+	Original   string `json:"original" description:"Term in the source language"`
+	Translated string `json:"translated" description:"Translation used for the term"`
+	Category   string `json:"category,omitempty" description:"character, place, sect, technique, item or title"`
 }
 
 // NovelExtractionRequest represents a request to extract novel details from a URL
 type NovelExtractionRequest struct {
-	URL         string  `json:"url"`
-	Source      string  `json:"source"`
+	URL            string  `json:"url"`
+	Source         string  `json:"source,omitempty"` // Detected from the URL when empty
+	Provider       string  `json:"provider,omitempty"`
+	TargetLanguage string  `json:"target_language,omitempty"`
+	Style          string  `json:"style,omitempty"`
+	NoCache        bool    `json:"no_cache,omitempty"`
+	HTMLContent    *string `json:"html_content"`
+}
+
+// TableOfContentsRequest represents a request to list all the chapters of a novel from its source
+type TableOfContentsRequest struct {
+	NovelID     string  `json:"novel_id"`
 	HTMLContent *string `json:"html_content"`
 }
 
+// SourceResolveRequest represents a request to find the source of a URL
+type SourceResolveRequest struct {
+	URL string `json:"url"`
+}
+
 // ChapterTranslationRequest represents a request to translate a chapter
 type ChapterTranslationRequest struct {
 	NovelID       string  `json:"novel_id"`
 	ChapterNumber int     `json:"chapter_number"`
 	ChapterURL    string  `json:"chapter_url,omitempty"`
+	Provider      string  `json:"provider,omitempty"`
+	Language      string  `json:"lang,omitempty"`
+	NoCache       bool    `json:"no_cache,omitempty"`
 	HTMLContent   *string `json:"html_content"`
 }
 
+// ChapterRetranslationRequest represents a request to translate an existing chapter again as a new version.
+// The provider, model and style default to those of a regular translation. Retranslations never use the cache.
+type ChapterRetranslationRequest struct {
+	NovelID     string  `json:"novel_id"`
+	ChapterID   string  `json:"chapter_id"`
+	Provider    string  `json:"provider,omitempty"`
+	Model       string  `json:"model,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	HTMLContent *string `json:"html_content"`
+}
+
+// ActiveVersionRequest represents a request to choose the version a chapter shows
+type ActiveVersionRequest struct {
+	Version int `json:"version"`
+}
+
+// NovelLanguageRequest represents a request to change the language a novel is translated into.
+// An empty target language makes the novel follow the global setting again.
+type NovelLanguageRequest struct {
+	TargetLanguage string `json:"target_language"`
+}
+
+// NovelStyleRequest represents a request to change the style preset a novel is translated in.
+// An empty style makes the novel follow the global setting again.
+type NovelStyleRequest struct {
+	Style string `json:"style"`
+}
+
 // NovelRefreshRequest represents a request to refresh a novel's details
 type NovelRefreshRequest struct {
 	NovelID     string  `json:"novel_id"`
+	Provider    string  `json:"provider,omitempty"`
+	NoCache     bool    `json:"no_cache,omitempty"`
 	HTMLContent *string `json:"html_content"`
 }
 
@@ -62,16 +123,9 @@ type NovelRefreshResponse struct {
 	} `json:"updatedDetails"`
 }
 
-// FirstChapterURLResponse represents the response when setting a first chapter URL
-type FirstChapterURLResponse struct {
-	Success           bool   `json:"success"`
-	Message           string `json:"message"`
-	FirstChapterURL   string `json:"firstChapterUrl,omitempty"`
-	URLPattern        string `json:"urlPattern,omitempty"`
-	ChapterTranslated bool   `json:"chapterTranslated,omitempty"`
-}
-
-// ChapterURLResponse represents the response when getting a chapter URL
+// ChapterURLResponse represents the response when getting a chapter URL. URLPattern is the URL of every chapter of the
+// novel with {number} standing for the chapter number, when the source numbers its chapter URLs.
 type ChapterURLResponse struct {
-	URL string `json:"url"`
+	URL        string `json:"url"`
+	URLPattern string `json:"url_pattern,omitempty"`
 }
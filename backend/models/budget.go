@@ -0,0 +1,23 @@
+package models
+
+// Budget scopes
+const (
+	BudgetScopeDaily   = "daily"
+	BudgetScopeMonthly = "monthly"
+	BudgetScopeNovel   = "novel"
+)
+
+// BudgetStatus represents a spending limit and how much of it is left.
+// A zero limit means the scope is not limited in tokens or in dollars, and has no remaining amount.
+type BudgetStatus struct {
+	Scope           string   `json:"scope"` // "daily", "monthly" or "novel"
+	NovelID         string   `json:"novel_id,omitempty"`
+	Since           int64    `json:"since,omitempty"`
+	TokenLimit      int      `json:"token_limit,omitempty"`
+	TokensUsed      int      `json:"tokens_used"`
+	TokensRemaining *int     `json:"tokens_remaining,omitempty"`
+	CostLimit       float64  `json:"cost_limit,omitempty"` // in USD
+	CostUsed        float64  `json:"cost_used"`
+	CostRemaining   *float64 `json:"cost_remaining,omitempty"`
+	Exceeded        bool     `json:"exceeded"`
+}
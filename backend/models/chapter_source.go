@@ -0,0 +1,68 @@
+package models
+
+import "database/sql"
+
+// ChapterSource represents the original of a translated chapter, as extracted from the scraped page
+type ChapterSource struct {
+	ChapterID string `json:"chapter_id"`
+	NovelID   string `json:"novel_id"`
+
+	// OriginalText is the text of the page with one paragraph per line
+	OriginalText string `json:"original_text"`
+
+	// SourceHash is the SHA-256 of the raw page, to tell whether the page changed since
+	SourceHash string `json:"source_hash"`
+
+	// Extracted tells whether the text is the chapter alone, as extracted by its source, rather than the whole page
+	// without its navigation
+	Extracted   bool  `json:"extracted"`
+	DateScraped int64 `json:"date_scraped"`
+}
+
+// ScanChapterSource scans a chapter source from a SQL row
+func ScanChapterSource(row *sql.Row) (*ChapterSource, error) {
+	var source ChapterSource
+
+	err := row.Scan(
+		&source.ChapterID,
+		&source.NovelID,
+		&source.OriginalText,
+		&source.SourceHash,
+		&source.Extracted,
+		&source.DateScraped,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &source, nil
+}
+
+// ScanChapterSources scans multiple chapter sources from SQL rows
+func ScanChapterSources(rows *sql.Rows) ([]*ChapterSource, error) {
+	var sources []*ChapterSource
+
+	for rows.Next() {
+		var source ChapterSource
+
+		err := rows.Scan(
+			&source.ChapterID,
+			&source.NovelID,
+			&source.OriginalText,
+			&source.SourceHash,
+			&source.Extracted,
+			&source.DateScraped,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, &source)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
@@ -0,0 +1,120 @@
+package models
+
+import "database/sql"
+
+// ChapterVersion is one translation of a chapter. The chapter itself holds the content of its active version.
+type ChapterVersion struct {
+	ID             string   `json:"id"`
+	ChapterID      string   `json:"chapter_id"`
+	NovelID        string   `json:"novel_id"`
+	Version        int      `json:"version"`
+	Title          string   `json:"title"`
+	OriginalTitle  string   `json:"original_title,omitempty"`
+	Content        string   `json:"content,omitempty"`
+	WordCount      int      `json:"word_count,omitempty"`
+	Provider       string   `json:"provider,omitempty"`
+	Model          string   `json:"model,omitempty"`
+	Style          string   `json:"style,omitempty"`
+	PromptVersion  string   `json:"prompt_version,omitempty"`
+	Attempts       int      `json:"attempts,omitempty"`
+	QualityScore   *float64 `json:"quality_score,omitempty"`
+	QualityFlags   []string `json:"quality_flags,omitempty"`
+	DateTranslated int64    `json:"date_translated"`
+
+	// Active tells whether the chapter currently shows this version
+	Active bool `json:"active"`
+}
+
+// ChapterDiff lists the paragraph changes between two versions of a chapter
+type ChapterDiff struct {
+	ChapterID string        `json:"chapter_id"`
+	From      int           `json:"from"`
+	To        int           `json:"to"`
+	Changes   []*DiffChange `json:"changes"`
+}
+
+// DiffOp tells how a paragraph changed between two versions
+type DiffOp string
+
+const (
+	DiffOpEqual  DiffOp = "equal"
+	DiffOpInsert DiffOp = "insert"
+	DiffOpDelete DiffOp = "delete"
+)
+
+// DiffChange is a paragraph kept, added or removed by the newer version
+type DiffChange struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// ScanChapterVersion scans a chapter version from a SQL row
+func ScanChapterVersion(row *sql.Row) (*ChapterVersion, error) {
+	var version ChapterVersion
+	var qualityScore sql.NullFloat64
+	var qualityFlagsJSON string
+
+	err := row.Scan(
+		&version.ID,
+		&version.ChapterID,
+		&version.NovelID,
+		&version.Version,
+		&version.Title,
+		&version.OriginalTitle,
+		&version.Content,
+		&version.WordCount,
+		&version.Provider,
+		&version.Model,
+		&version.Style,
+		&version.PromptVersion,
+		&version.Attempts,
+		&qualityScore,
+		&qualityFlagsJSON,
+		&version.DateTranslated,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	version.QualityScore, version.QualityFlags = parseQuality(qualityScore, qualityFlagsJSON)
+
+	return &version, nil
+}
+
+// ScanChapterVersions scans multiple chapter versions, without their content, from SQL rows
+func ScanChapterVersions(rows *sql.Rows) ([]*ChapterVersion, error) {
+	var versions []*ChapterVersion
+
+	for rows.Next() {
+		var version ChapterVersion
+		var qualityScore sql.NullFloat64
+		var qualityFlagsJSON string
+
+		err := rows.Scan(
+			&version.ID,
+			&version.ChapterID,
+			&version.NovelID,
+			&version.Version,
+			&version.Title,
+			&version.OriginalTitle,
+			&version.WordCount,
+			&version.Provider,
+			&version.Model,
+			&version.Style,
+			&version.PromptVersion,
+			&version.Attempts,
+			&qualityScore,
+			&qualityFlagsJSON,
+			&version.DateTranslated,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		version.QualityScore, version.QualityFlags = parseQuality(qualityScore, qualityFlagsJSON)
+
+		versions = append(versions, &version)
+	}
+
+	return versions, nil
+}
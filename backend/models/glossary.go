@@ -0,0 +1,136 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// GlossaryTerm represents an original term of a novel and the translation that must be used for it
+type GlossaryTerm struct {
+	ID         string `json:"id"`
+	NovelID    string `json:"novel_id"`
+	Original   string `json:"original"`
+	Translated string `json:"translated"`
+	Category   string `json:"category,omitempty"` // e.g. "character", "place", "sect", "technique"
+	Notes      string `json:"notes,omitempty"`
+
+	// AutoExtracted is set for terms added from the translations instead of by hand
+	AutoExtracted bool `json:"auto_extracted"`
+
+	// ConflictingTranslation is set when a later chapter translated the term differently
+	ConflictingTranslation string `json:"conflicting_translation,omitempty"`
+	ConflictChapterNumber  int    `json:"conflict_chapter_number,omitempty"`
+
+	DateAdded   int64 `json:"date_added"`
+	LastUpdated int64 `json:"last_updated"`
+}
+
+// GlossaryTermUpdateRequest represents a request to edit a glossary term. AutoExtracted is only changed when the
+// request sets it, so that edited terms keep telling where they came from.
+type GlossaryTermUpdateRequest struct {
+	GlossaryTerm
+	AutoExtracted *bool `json:"auto_extracted,omitempty"`
+}
+
+// ChapterContext holds what an LLM needs to know about the novel besides the chapter itself
+type ChapterContext struct {
+	// TargetLanguage is the language the chapter is translated into
+	TargetLanguage string
+
+	NovelGenres []string
+	Glossary    []*GlossaryTerm
+
+	// StorySummary is the rolling summary of the story up to the previous chapter
+	StorySummary string
+
+	// PreviousChapterTail is the end of the previous chapter's translation, as plain text
+	PreviousChapterTail string
+
+	// Part and TotalParts are set when a long chapter is translated in several parts
+	Part       int
+	TotalParts int
+
+	// SummarizePartOnly asks for a summary of the events of the part alone, for the parts translated at the same
+	// time as the ones before them
+	SummarizePartOnly bool
+
+	// QualityFeedback explains why the previous translation of the chapter failed the quality checks
+	QualityFeedback string
+
+	// StyleInstructions are the prompt fragment of the style preset the chapter is translated in
+	StyleInstructions string
+}
+
+// ScanGlossaryTerm scans a glossary term from a SQL row
+func ScanGlossaryTerm(row *sql.Row) (*GlossaryTerm, error) {
+	var term GlossaryTerm
+
+	err := row.Scan(
+		&term.ID,
+		&term.NovelID,
+		&term.Original,
+		&term.Translated,
+		&term.Category,
+		&term.Notes,
+		&term.AutoExtracted,
+		&term.ConflictingTranslation,
+		&term.ConflictChapterNumber,
+		&term.DateAdded,
+		&term.LastUpdated,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &term, nil
+}
+
+// ScanGlossaryTerms scans multiple glossary terms from SQL rows
+func ScanGlossaryTerms(rows *sql.Rows) ([]*GlossaryTerm, error) {
+	var terms []*GlossaryTerm
+
+	for rows.Next() {
+		var term GlossaryTerm
+
+		err := rows.Scan(
+			&term.ID,
+			&term.NovelID,
+			&term.Original,
+			&term.Translated,
+			&term.Category,
+			&term.Notes,
+			&term.AutoExtracted,
+			&term.ConflictingTranslation,
+			&term.ConflictChapterNumber,
+			&term.DateAdded,
+			&term.LastUpdated,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		terms = append(terms, &term)
+	}
+
+	return terms, nil
+}
+
+// GlossaryToString formats glossary terms as one "original => translated" pair per line for use in prompts
+func GlossaryToString(terms []*GlossaryTerm) string {
+	if len(terms) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	for _, term := range terms {
+		builder.WriteString("\n- ")
+		builder.WriteString(term.Original)
+		builder.WriteString(" => ")
+		builder.WriteString(term.Translated)
+		if term.Category != "" {
+			builder.WriteString(" (" + term.Category + ")")
+		}
+	}
+
+	return builder.String()
+}
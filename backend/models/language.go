@@ -0,0 +1,38 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// LegacyLanguage is the language of the chapters translated before the target language was configurable.
+// Chapters in this language keep the chapter ID of their source.
+const LegacyLanguage = "English"
+
+// NormalizeLanguage trims a language name and capitalizes its words, e.g. " brazilian portuguese" becomes "Brazilian Portuguese"
+func NormalizeLanguage(language string) string {
+	words := strings.Fields(strings.ToLower(language))
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+// ChapterIDForLanguage returns the ID of the translation of a source chapter into the language
+func ChapterIDForLanguage(chapterID, language string) string {
+	if language == LegacyLanguage || chapterID == "" {
+		return chapterID
+	}
+	return chapterID + "-" + strings.ToLower(strings.ReplaceAll(language, " ", "-"))
+}
+
+// IsCJKLanguage reports whether the language is written with Chinese, Japanese or Korean characters
+func IsCJKLanguage(language string) bool {
+	switch NormalizeLanguage(language) {
+	case "Chinese", "Simplified Chinese", "Traditional Chinese", "Japanese", "Korean", "Cantonese":
+		return true
+	}
+	return false
+}
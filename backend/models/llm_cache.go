@@ -0,0 +1,32 @@
+package models
+
+import "database/sql"
+
+// LLMCacheEntry represents a stored LLM response, keyed by a hash of the provider, model, prompt version and input
+type LLMCacheEntry struct {
+	Key       string `json:"key"`
+	Provider  string `json:"provider"`
+	Model     string `json:"model"`
+	Operation string `json:"operation"`
+	Response  string `json:"response"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// ScanLLMCacheEntry scans an LLM cache entry from a SQL row
+func ScanLLMCacheEntry(row *sql.Row) (*LLMCacheEntry, error) {
+	var entry LLMCacheEntry
+
+	err := row.Scan(
+		&entry.Key,
+		&entry.Provider,
+		&entry.Model,
+		&entry.Operation,
+		&entry.Response,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
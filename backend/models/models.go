@@ -22,6 +22,12 @@ type Novel struct {
 	LastReadTimestamp     int64    `json:"last_read_timestamp,omitempty"`
 	LastUpdated           int64    `json:"last_updated"`
 	DateAdded             int64    `json:"date_added"`
+
+	// TargetLanguage overrides the globally configured language the novel is translated into
+	TargetLanguage string `json:"target_language,omitempty"`
+
+	// Style overrides the globally configured translation style preset of the novel
+	Style string `json:"style,omitempty"`
 }
 
 // Chapter represents a chapter in the database
@@ -36,6 +42,31 @@ type Chapter struct {
 	WordCount      int    `json:"word_count,omitempty"`
 	URL            string `json:"url,omitempty"`
 	NextChapterURL string `json:"next_chapter_url,omitempty"`
+	Provider       string `json:"provider,omitempty"`
+	Attempts       int    `json:"attempts,omitempty"`
+
+	// Language is the language the chapter is translated into. A chapter can be stored in several languages.
+	Language string `json:"language"`
+
+	// Version is the number of the active translation of the chapter, 0 for chapters translated before versions were kept
+	Version int `json:"version,omitempty"`
+
+	// Style is the style preset the chapter was translated in, empty for chapters translated before the presets existed
+	Style string `json:"style,omitempty"`
+
+	// PromptVersion is the version of the prompt template the chapter was translated with
+	PromptVersion string `json:"prompt_version,omitempty"`
+
+	// Original is only loaded when asked for
+	Original *ChapterSource `json:"original,omitempty"`
+
+	// Translated is false for the chapters of the table of contents of the novel that are not translated yet
+	Translated bool `json:"translated"`
+
+	// QualityScore and QualityFlags are set by the quality checks run after translation.
+	// Chapters translated before the checks existed have no score.
+	QualityScore *float64 `json:"quality_score,omitempty"`
+	QualityFlags []string `json:"quality_flags,omitempty"`
 }
 
 // SourceSite represents a source site for novels
@@ -45,6 +76,28 @@ type SourceSite struct {
 	URL      string `json:"url"`
 	Language string `json:"language"` // "Chinese", "Korean", "Japanese", "Other"
 	Icon     string `json:"icon,omitempty"`
+
+	// Mirrors are the other base URLs the site is served from
+	Mirrors []string `json:"mirrors,omitempty"`
+
+	// Capabilities lists what the source can do beyond finding novel and chapter IDs, next chapters and covers
+	Capabilities []string `json:"capabilities"`
+}
+
+// Page types of the URLs resolved to a source
+const (
+	PageTypeNovel   = "novel"
+	PageTypeChapter = "chapter"
+)
+
+// SourceResolution is the source a URL belongs to, with the novel it is about
+type SourceResolution struct {
+	Source string `json:"source"`
+
+	// NovelID is empty for the chapter URLs that do not contain the ID of their novel
+	NovelID   string `json:"novel_id"`
+	ChapterID string `json:"chapter_id,omitempty"`
+	PageType  string `json:"page_type"`
 }
 
 // ScanNovel scans a novel from a SQL row
@@ -69,6 +122,8 @@ func ScanNovel(row *sql.Row) (*Novel, error) {
 		&novel.LastReadTimestamp,
 		&lastUpdatedUnix,
 		&dateAddedUnix,
+		&novel.TargetLanguage,
+		&novel.Style,
 	)
 	if err != nil {
 		return nil, err
@@ -113,6 +168,8 @@ func ScanNovels(rows *sql.Rows) ([]*Novel, error) {
 			&novel.LastReadTimestamp,
 			&lastUpdatedUnix,
 			&dateAddedUnix,
+			&novel.TargetLanguage,
+			&novel.Style,
 		)
 		if err != nil {
 			return nil, err
@@ -139,6 +196,8 @@ func ScanNovels(rows *sql.Rows) ([]*Novel, error) {
 func ScanChapter(row *sql.Row) (*Chapter, error) {
 	var chapter Chapter
 	var dateTranslatedUnix int64
+	var qualityScore sql.NullFloat64
+	var qualityFlagsJSON string
 
 	err := row.Scan(
 		&chapter.ID,
@@ -151,12 +210,22 @@ func ScanChapter(row *sql.Row) (*Chapter, error) {
 		&chapter.WordCount,
 		&chapter.URL,
 		&chapter.NextChapterURL,
+		&chapter.Provider,
+		&chapter.Attempts,
+		&qualityScore,
+		&qualityFlagsJSON,
+		&chapter.Language,
+		&chapter.Version,
+		&chapter.Style,
+		&chapter.PromptVersion,
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	chapter.DateTranslated = dateTranslatedUnix
+	chapter.setQuality(qualityScore, qualityFlagsJSON)
+	chapter.Translated = true
 
 	return &chapter, nil
 }
@@ -167,6 +236,8 @@ func ScanChapters(rows *sql.Rows) ([]*Chapter, error) {
 
 	for rows.Next() {
 		var chapter Chapter
+		var qualityScore sql.NullFloat64
+		var qualityFlagsJSON string
 
 		err := rows.Scan(
 			&chapter.ID,
@@ -178,17 +249,64 @@ func ScanChapters(rows *sql.Rows) ([]*Chapter, error) {
 			&chapter.WordCount,
 			&chapter.URL,
 			&chapter.NextChapterURL,
+			&chapter.Provider,
+			&chapter.Attempts,
+			&qualityScore,
+			&qualityFlagsJSON,
+			&chapter.Language,
+			&chapter.Version,
+			&chapter.Style,
+			&chapter.PromptVersion,
 		)
 		if err != nil {
 			return nil, err
 		}
 
+		chapter.setQuality(qualityScore, qualityFlagsJSON)
+		chapter.Translated = true
+
 		chapters = append(chapters, &chapter)
 	}
 
 	return chapters, nil
 }
 
+// setQuality fills in the quality score and flags scanned from the database
+func (c *Chapter) setQuality(score sql.NullFloat64, flagsJSON string) {
+	c.QualityScore, c.QualityFlags = parseQuality(score, flagsJSON)
+}
+
+// parseQuality decodes a quality score and flags scanned from the database
+func parseQuality(score sql.NullFloat64, flagsJSON string) (*float64, []string) {
+	var qualityScore *float64
+	if score.Valid {
+		qualityScore = &score.Float64
+	}
+
+	var flags []string
+	if flagsJSON != "" {
+		if err := json.Unmarshal([]byte(flagsJSON), &flags); err != nil {
+			flags = nil
+		}
+	}
+
+	return qualityScore, flags
+}
+
+// QualityFlagsToJSON converts the quality flags of a chapter to a JSON string for storage
+func QualityFlagsToJSON(flags []string) (string, error) {
+	if len(flags) == 0 {
+		return "", nil
+	}
+
+	bytes, err := json.Marshal(flags)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
+
 // GenresToJSON converts a slice of genre strings to a JSON string for storage
 func GenresToJSON(genres []string) (string, error) {
 	if len(genres) == 0 {
@@ -0,0 +1,11 @@
+package models
+
+// PromptTemplate represents a prompt template in use for an LLM operation
+type PromptTemplate struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	// Source is "embedded" for the templates shipped with the server, or the path of the file overriding them
+	Source   string `json:"source"`
+	Template string `json:"template"`
+}
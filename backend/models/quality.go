@@ -0,0 +1,9 @@
+package models
+
+// Quality flags set on a chapter whose translation failed a quality check
+const (
+	QualityFlagShortTranslation  = "short_translation"  // much shorter than the source
+	QualityFlagResidualCJK       = "residual_cjk"       // Chinese, Japanese or Korean text left untranslated
+	QualityFlagMissingParagraphs = "missing_paragraphs" // far fewer paragraphs than the source
+	QualityFlagEmptyTitle        = "empty_title"
+)
@@ -0,0 +1,28 @@
+package models
+
+import "database/sql"
+
+// StoryContext represents the rolling summary of a novel, updated after each translated chapter
+type StoryContext struct {
+	NovelID           string `json:"novel_id"`
+	Summary           string `json:"summary"`
+	LastChapterNumber int    `json:"last_chapter_number"`
+	LastUpdated       int64  `json:"last_updated"`
+}
+
+// ScanStoryContext scans a story context from a SQL row
+func ScanStoryContext(row *sql.Row) (*StoryContext, error) {
+	var storyContext StoryContext
+
+	err := row.Scan(
+		&storyContext.NovelID,
+		&storyContext.Summary,
+		&storyContext.LastChapterNumber,
+		&storyContext.LastUpdated,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storyContext, nil
+}
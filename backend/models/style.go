@@ -0,0 +1,98 @@
+package models
+
+import "database/sql"
+
+// Names of the built-in translation styles
+const (
+	StyleLiteral  = "literal"
+	StyleBalanced = "balanced"
+	StylePolished = "polished"
+)
+
+// StylePreset is a named translation style, passed to the LLM as a fragment of the chapter prompt
+type StylePreset struct {
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Instructions string `json:"instructions"`
+
+	// BuiltIn presets come with the application and cannot be changed
+	BuiltIn bool `json:"built_in"`
+
+	DateAdded   int64 `json:"date_added,omitempty"`
+	LastUpdated int64 `json:"last_updated,omitempty"`
+}
+
+// BuiltInStylePresets are the styles available without configuration. Polished is how every chapter was
+// translated before the styles existed.
+var BuiltInStylePresets = []*StylePreset{
+	{
+		Name:         StyleLiteral,
+		Description:  "Faithful translation keeping the wording and structure of the original",
+		Instructions: "Translate faithfully and literally. Keep the sentence structure, paragraphing, word choice and tone of the original as closely as the target language allows. Do not embellish, rephrase for style, merge or reorder sentences; only adjust what would otherwise be ungrammatical.",
+		BuiltIn:      true,
+	},
+	{
+		Name:         StyleBalanced,
+		Description:  "Faithful translation reading naturally",
+		Instructions: "Translate faithfully while making the text read naturally. Stay close to the meaning, sentence order and tone of the original, but rephrase awkward or unidiomatic constructions so that narration and dialogues sound natural. Do not add or remove content.",
+		BuiltIn:      true,
+	},
+	{
+		Name:         StylePolished,
+		Description:  "Engaging translation smoothing out the writing of the original",
+		Instructions: "Your goal is to craft an engaging version while preserving the original content. The author of the original text may have limited writing skills, so take the liberty to polish the content in your translation to ensure that the sentences and paragraphs flow smoothly. Pay special attention to the dialogues, ensuring they flow smoothly and sound lifelike.",
+		BuiltIn:      true,
+	},
+}
+
+// BuiltInStylePreset returns the built-in preset with the name, or nil
+func BuiltInStylePreset(name string) *StylePreset {
+	for _, preset := range BuiltInStylePresets {
+		if preset.Name == name {
+			return preset
+		}
+	}
+	return nil
+}
+
+// ScanStylePresets scans multiple custom style presets from SQL rows
+func ScanStylePresets(rows *sql.Rows) ([]*StylePreset, error) {
+	var presets []*StylePreset
+
+	for rows.Next() {
+		var preset StylePreset
+
+		err := rows.Scan(
+			&preset.Name,
+			&preset.Description,
+			&preset.Instructions,
+			&preset.DateAdded,
+			&preset.LastUpdated,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		presets = append(presets, &preset)
+	}
+
+	return presets, nil
+}
+
+// ScanStylePreset scans a custom style preset from a SQL row
+func ScanStylePreset(row *sql.Row) (*StylePreset, error) {
+	var preset StylePreset
+
+	err := row.Scan(
+		&preset.Name,
+		&preset.Description,
+		&preset.Instructions,
+		&preset.DateAdded,
+		&preset.LastUpdated,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &preset, nil
+}
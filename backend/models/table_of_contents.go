@@ -0,0 +1,38 @@
+package models
+
+import "database/sql"
+
+// TableOfContentsEntry is a chapter listed in the table of contents of a novel, whether it is translated or not
+type TableOfContentsEntry struct {
+	NovelID string `json:"novel_id"`
+
+	// Number is the position of the chapter in the table of contents, starting at 1
+	Number        int    `json:"number"`
+	URL           string `json:"url"`
+	OriginalTitle string `json:"original_title"`
+	DateScraped   int64  `json:"date_scraped"`
+}
+
+// ScanTableOfContents scans the entries of a table of contents from SQL rows
+func ScanTableOfContents(rows *sql.Rows) ([]*TableOfContentsEntry, error) {
+	var entries []*TableOfContentsEntry
+
+	for rows.Next() {
+		var entry TableOfContentsEntry
+
+		err := rows.Scan(
+			&entry.NovelID,
+			&entry.Number,
+			&entry.URL,
+			&entry.OriginalTitle,
+			&entry.DateScraped,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, nil
+}
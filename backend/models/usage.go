@@ -0,0 +1,68 @@
+package models
+
+import "database/sql"
+
+// LLMUsage represents the tokens used and the cost of a single LLM call
+type LLMUsage struct {
+	ID               string  `json:"id"`
+	NovelID          string  `json:"novel_id,omitempty"`
+	ChapterNumber    int     `json:"chapter_number,omitempty"`
+	Operation        string  `json:"operation"` // "novel_details" or "chapter"
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	CacheReadTokens  int     `json:"cache_read_tokens"`
+	CacheWriteTokens int     `json:"cache_write_tokens"`
+	Cost             float64 `json:"cost"` // in USD
+	Timestamp        int64   `json:"timestamp"`
+}
+
+// UsageSummary represents the usage aggregated over a group of LLM calls
+type UsageSummary struct {
+	Key              string  `json:"key,omitempty"`
+	Name             string  `json:"name,omitempty"`
+	Calls            int     `json:"calls"`
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	CacheReadTokens  int     `json:"cache_read_tokens"`
+	CacheWriteTokens int     `json:"cache_write_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// UsageStats represents the LLM usage over a period with per-day, per-novel and per-provider breakdowns
+type UsageStats struct {
+	Since      int64           `json:"since"`
+	Total      *UsageSummary   `json:"total"`
+	ByDay      []*UsageSummary `json:"by_day"`
+	ByNovel    []*UsageSummary `json:"by_novel"`
+	ByProvider []*UsageSummary `json:"by_provider"`
+	Budgets    []*BudgetStatus `json:"budgets"`
+}
+
+// ScanUsageSummaries scans multiple usage summaries from SQL rows
+func ScanUsageSummaries(rows *sql.Rows) ([]*UsageSummary, error) {
+	summaries := []*UsageSummary{}
+
+	for rows.Next() {
+		var summary UsageSummary
+
+		err := rows.Scan(
+			&summary.Key,
+			&summary.Name,
+			&summary.Calls,
+			&summary.InputTokens,
+			&summary.OutputTokens,
+			&summary.CacheReadTokens,
+			&summary.CacheWriteTokens,
+			&summary.Cost,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		summaries = append(summaries, &summary)
+	}
+
+	return summaries, nil
+}
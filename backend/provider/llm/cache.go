@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+
+	"backend/models"
+)
+
+// ResponseCache stores LLM responses under a hash of everything that determines them
+type ResponseCache interface {
+	GetResponse(key string) (response string, found bool, err error)
+	SaveResponse(key, provider, model, operation, response string) error
+}
+
+var responseCache ResponseCache
+
+type cacheBypassKey struct{}
+
+// SetResponseCache sets the cache used by the caching clients
+func SetResponseCache(cache ResponseCache) {
+	responseCache = cache
+}
+
+// WithCacheBypass returns a context whose LLM calls skip the cache lookup when bypass is true.
+// The fresh responses are still stored, replacing the cached ones.
+func WithCacheBypass(ctx context.Context, bypass bool) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, bypass)
+}
+
+// cachingClient returns the stored response of a provider for inputs it has already translated,
+// without making a new paid call
+type cachingClient struct {
+	provider string
+	model    string
+	client   IClient
+}
+
+// NewCachingClient wraps the client of a provider and model with the response cache
+func NewCachingClient(provider, model string, client IClient) IClient {
+	return &cachingClient{
+		provider: provider,
+		model:    model,
+		client:   client,
+	}
+}
+
+func (c *cachingClient) TranslateNovelDetails(ctx context.Context, targetLanguage string, webpageContent string) (*models.NovelDetails, error) {
+	key := c.cacheKey(ctx, OperationNovelDetails, targetLanguageOrDefault(targetLanguage), webpageContent)
+	return cached(ctx, c, key, OperationNovelDetails, func() (*models.NovelDetails, error) {
+		return c.client.TranslateNovelDetails(ctx, targetLanguage, webpageContent)
+	})
+}
+
+func (c *cachingClient) TranslateNovelChapter(ctx context.Context, chapterContext *models.ChapterContext, webpageContent string) (*models.TranslatedChapter, error) {
+	key := c.cacheKey(ctx, OperationChapter, chapterContextKey(chapterContext), webpageContent)
+	return cached(ctx, c, key, OperationChapter, func() (*models.TranslatedChapter, error) {
+		return c.client.TranslateNovelChapter(ctx, chapterContext, webpageContent)
+	})
+}
+
+// cached returns the cached response for the key, or calls the client and caches its response.
+// Cache failures are logged and never fail the translation.
+func cached[T any](ctx context.Context, c *cachingClient, key, operation string, call func() (*T, error)) (*T, error) {
+	if responseCache == nil {
+		return call()
+	}
+
+	if bypass, _ := ctx.Value(cacheBypassKey{}).(bool); !bypass {
+		response, found, err := responseCache.GetResponse(key)
+		if err != nil {
+			log.Printf("Failed to read the LLM response cache: %v", err)
+		} else if found {
+			var result T
+			if err = json.Unmarshal([]byte(response), &result); err == nil {
+				log.Printf("Using cached %s response of provider %s", operation, c.provider)
+				return &result, nil
+			}
+			log.Printf("Ignoring unreadable cached %s response of provider %s: %v", operation, c.provider, err)
+		}
+	}
+
+	result, err := call()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Failed to encode %s response of provider %s for the cache: %v", operation, c.provider, err)
+		return result, nil
+	}
+	if err = responseCache.SaveResponse(key, c.provider, modelOrDefault(ctx, c.model), operation, string(response)); err != nil {
+		log.Printf("Failed to save %s response of provider %s in the cache: %v", operation, c.provider, err)
+	}
+
+	return result, nil
+}
+
+// cacheKey hashes the provider, model, prompt version, operation and the whole input of the call
+func (c *cachingClient) cacheKey(ctx context.Context, operation, promptContext, input string) string {
+	hash := sha256.New()
+	for _, part := range []string{c.provider, modelOrDefault(ctx, c.model), PromptVersion(operation), operation, promptContext, input} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// chapterContextKey encodes everything of the chapter context that ends up in the prompt.
+// Glossary terms are reduced to their text so that edits to their metadata keep the cache valid.
+func chapterContextKey(chapterContext *models.ChapterContext) string {
+	if chapterContext == nil {
+		return ""
+	}
+
+	prompted := *chapterContext
+	prompted.Glossary = nil
+
+	encoded, _ := json.Marshal(struct {
+		Context  models.ChapterContext
+		Glossary string
+	}{
+		Context:  prompted,
+		Glossary: models.GlossaryToString(chapterContext.Glossary),
+	})
+	return string(encoded)
+}
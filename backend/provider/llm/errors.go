@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+)
+
+var (
+	// ErrInvalidResponse is returned when a provider answers with something that cannot be parsed
+	ErrInvalidResponse = errors.New("invalid LLM response")
+
+	// ErrTruncatedResponse is returned when a provider stops before the response is complete
+	ErrTruncatedResponse = errors.New("truncated LLM response")
+
+	// ErrContentRefused is returned when a provider refuses to process the content
+	ErrContentRefused = errors.New("content refused by LLM")
+
+	// ErrProviderUnavailable is returned by a provider whose client could not be created, e.g. for lack of credentials.
+	// It is retryable so that a fallback chain moves on to the next provider.
+	ErrProviderUnavailable = errors.New("LLM provider unavailable")
+
+	// ErrUnknownProvider is returned for a provider name no client is registered under
+	ErrUnknownProvider = errors.New("unknown LLM provider")
+)
+
+// ErrorKind tells whether a failed LLM call is worth trying again, possibly with another provider
+type ErrorKind int
+
+const (
+	ErrorKindRetryable ErrorKind = iota
+	ErrorKindFatal
+)
+
+func (k ErrorKind) String() string {
+	if k == ErrorKindFatal {
+		return "fatal"
+	}
+	return "retryable"
+}
+
+// ClassifyError decides whether an error returned by a provider is retryable
+// (throttling, server errors, truncated or invalid JSON) or fatal (auth, content refusal).
+// Unknown errors are treated as retryable.
+func ClassifyError(err error) ErrorKind {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ErrorKindFatal
+	case errors.Is(err, ErrContentRefused):
+		return ErrorKindFatal
+	case errors.Is(err, ErrInvalidResponse), errors.Is(err, ErrTruncatedResponse), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, ErrProviderUnavailable):
+		return ErrorKindRetryable
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return ErrorKindRetryable
+	}
+
+	// Bedrock errors carry an error code
+	var codeErr interface{ ErrorCode() string }
+	if errors.As(err, &codeErr) {
+		switch codeErr.ErrorCode() {
+		case "ThrottlingException", "ServiceUnavailableException", "InternalServerException",
+			"ModelTimeoutException", "ModelNotReadyException", "ServiceQuotaExceededException":
+			return ErrorKindRetryable
+		case "AccessDeniedException", "UnrecognizedClientException", "ExpiredTokenException",
+			"InvalidSignatureException", "ValidationException":
+			return ErrorKindFatal
+		}
+	}
+
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		return classifyStatusCode(statusErr.HTTPStatusCode())
+	}
+
+	var openaiAPIErr *openai.APIError
+	if errors.As(err, &openaiAPIErr) {
+		return classifyStatusCode(openaiAPIErr.HTTPStatusCode)
+	}
+
+	var openaiRequestErr *openai.RequestError
+	if errors.As(err, &openaiRequestErr) {
+		return classifyStatusCode(openaiRequestErr.HTTPStatusCode)
+	}
+
+	var genaiErr genai.APIError
+	if errors.As(err, &genaiErr) {
+		return classifyStatusCode(genaiErr.Code)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrorKindRetryable
+	}
+
+	return ErrorKindRetryable
+}
+
+// IsRetryable reports whether the error is classified as retryable
+func IsRetryable(err error) bool {
+	return ClassifyError(err) == ErrorKindRetryable
+}
+
+func classifyStatusCode(code int) ErrorKind {
+	switch {
+	case code == http.StatusTooManyRequests, code == http.StatusRequestTimeout, code >= 500:
+		return ErrorKindRetryable
+	case code >= 400:
+		return ErrorKindFatal
+	default:
+		return ErrorKindRetryable
+	}
+}
@@ -0,0 +1,220 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"backend/models"
+	"backend/utils"
+)
+
+// Behaviors of the fake provider, set per call with a "[[fake:<behavior>]]" marker in the input
+// or for successive calls with the comma-separated LLM_FAKE_SCRIPT environment variable
+const (
+	FakeBehaviorOK        = "ok"
+	FakeBehaviorMalformed = "malformed" // cut-off JSON, an invalid response
+	FakeBehaviorTruncated = "truncated" // stopped at the max tokens limit
+	FakeBehaviorError     = "error"     // 503 Service Unavailable, retryable
+	FakeBehaviorFatal     = "fatal"     // 401 Unauthorized, fatal
+	FakeBehaviorRefused   = "refused"   // content refused
+)
+
+// fakeModel is the model name the fake provider records its usage under
+const fakeModel = "fake"
+
+var fakeMarkerRegex = regexp.MustCompile(`\[\[fake:(\w+)\]\]`)
+
+// fakeWords stand in for the translation of CJK characters
+var fakeWords = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit"}
+
+// fakeStatusError mimics an HTTP error of a real provider
+type fakeStatusError struct {
+	statusCode int
+}
+
+func (e *fakeStatusError) Error() string {
+	return fmt.Sprintf("fake provider error: %d %s", e.statusCode, http.StatusText(e.statusCode))
+}
+
+func (e *fakeStatusError) HTTPStatusCode() int {
+	return e.statusCode
+}
+
+// fakeClientImpl returns deterministic translations derived from the input, without any network call.
+// It lets the whole API be exercised offline and without credentials.
+type fakeClientImpl struct {
+	mu     sync.Mutex
+	script []string
+	calls  int
+}
+
+// NewFakeClient creates a fake client playing the given behaviors for successive calls, in a loop.
+// An empty script always succeeds.
+func NewFakeClient(script []string) IClient {
+	return &fakeClientImpl{
+		script: script,
+	}
+}
+
+// newFakeClientFromEnv creates the fake client scripted by the LLM_FAKE_SCRIPT environment variable, e.g. "ok,malformed,error"
+func newFakeClientFromEnv() IClient {
+	var script []string
+	if value := os.Getenv("LLM_FAKE_SCRIPT"); value != "" {
+		for _, behavior := range strings.Split(value, ",") {
+			script = append(script, strings.TrimSpace(behavior))
+		}
+	}
+	return NewFakeClient(script)
+}
+
+func (f *fakeClientImpl) TranslateNovelDetails(ctx context.Context, targetLanguage string, webpageContent string) (*models.NovelDetails, error) {
+	text := utils.HTMLToText(webpageContent)
+	hash := fakeHash(webpageContent)
+
+	novelDetails := &models.NovelDetails{
+		NovelTitleOriginal:        fakeFirstLine(text),
+		NovelTitleTranslated:      "Fake Novel " + hash,
+		NovelSummaryTranslated:    "<p>Fake summary of " + html.EscapeString(fakeFirstLine(text)) + ".</p>",
+		NovelAuthorNameTranslated: "Fake Author",
+		PossibleNovelGenres:       []string{"Fantasy"},
+		NumberOfChapters:          1 + int(hash[0])%50,
+		Status:                    "Ongoing",
+	}
+
+	content, err := f.respond(ctx, webpageContent, novelDetails)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNovelDetails(content)
+}
+
+func (f *fakeClientImpl) TranslateNovelChapter(ctx context.Context, chapterContext *models.ChapterContext, webpageContent string) (*models.TranslatedChapter, error) {
+	text := utils.HTMLToText(webpageContent)
+	hash := fakeHash(webpageContent)
+
+	tag := "[fake]"
+	if language := targetLanguageOrDefault(chapterContext.TargetLanguage); language != models.LegacyLanguage {
+		tag = "[fake " + language + "]"
+	}
+
+	var contents strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			contents.WriteString("<p>" + html.EscapeString(tag) + " " + html.EscapeString(fakeTranslateLine(line)) + "</p>")
+		}
+	}
+
+	summary := chapterContext.StorySummary
+	if chapterContext.SummarizePartOnly {
+		summary = ""
+	}
+
+	translatedChapter := &models.TranslatedChapter{
+		OriginalChapterTitle:      fakeFirstLine(text),
+		TranslatedChapterContents: contents.String(),
+		UpdatedStorySummary:       strings.TrimSpace(utils.TailText(summary+" Fake chapter "+hash+" happened.", 2000)),
+	}
+	if chapterContext.Part <= 1 {
+		translatedChapter.TranslatedChapterTitle = "Fake Chapter " + hash
+	}
+
+	content, err := f.respond(ctx, webpageContent, translatedChapter)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTranslatedChapter(content, chapterContext)
+}
+
+// respond plays the behavior of the call: it encodes the response as JSON and breaks it as scripted,
+// leaving the parsing to the shared parsing layer like for a real provider
+func (f *fakeClientImpl) respond(ctx context.Context, input string, response any) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	content, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+
+	recordUsage(ctx, ProviderFake, modelOrDefault(ctx, fakeModel), utils.EstimateTokens(input), utils.EstimateTokens(string(content)), 0, 0)
+
+	switch behavior := f.nextBehavior(input); behavior {
+	case FakeBehaviorOK:
+		return string(content), nil
+	case FakeBehaviorMalformed:
+		return string(content[:len(content)/2]), nil
+	case FakeBehaviorTruncated:
+		return "", fmt.Errorf("%w: fake provider stopped at max tokens", ErrTruncatedResponse)
+	case FakeBehaviorError:
+		return "", &fakeStatusError{statusCode: http.StatusServiceUnavailable}
+	case FakeBehaviorFatal:
+		return "", &fakeStatusError{statusCode: http.StatusUnauthorized}
+	case FakeBehaviorRefused:
+		return "", fmt.Errorf("%w: fake provider refused the content", ErrContentRefused)
+	default:
+		return "", fmt.Errorf("unknown fake provider behavior: %s", behavior)
+	}
+}
+
+// nextBehavior returns the behavior requested by a marker in the input, or the next one of the script
+func (f *fakeClientImpl) nextBehavior(input string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	call := f.calls
+	f.calls++
+
+	if match := fakeMarkerRegex.FindStringSubmatch(input); match != nil {
+		return match[1]
+	}
+	if len(f.script) == 0 {
+		return FakeBehaviorOK
+	}
+	return f.script[call%len(f.script)]
+}
+
+// fakeTranslateLine replaces every CJK character with a word, so that the fake translation has
+// no residual source text and a length proportional to the source
+func fakeTranslateLine(line string) string {
+	var words []string
+	var other strings.Builder
+	for _, r := range line {
+		if !utils.IsCJK(r) {
+			other.WriteRune(r)
+			continue
+		}
+		if other.Len() > 0 {
+			words = append(words, strings.TrimSpace(other.String()))
+			other.Reset()
+		}
+		words = append(words, fakeWords[int(r)%len(fakeWords)])
+	}
+	if other.Len() > 0 {
+		words = append(words, strings.TrimSpace(other.String()))
+	}
+	return strings.Join(words, " ")
+}
+
+func fakeHash(input string) string {
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:4])
+}
+
+func fakeFirstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	runes := []rune(line)
+	if len(runes) > 100 {
+		line = string(runes[:100])
+	}
+	return line
+}
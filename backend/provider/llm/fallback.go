@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"backend/models"
+)
+
+// fallbackClient tries an ordered chain of providers until one of them succeeds.
+// Retryable errors move on to the next provider, fatal errors stop the chain.
+type fallbackClient struct {
+	chain []string
+}
+
+// NewFallbackClient creates a client that tries the given providers in order
+func NewFallbackClient(chain []string) IClient {
+	return &fallbackClient{
+		chain: chain,
+	}
+}
+
+func (f *fallbackClient) TranslateNovelDetails(ctx context.Context, targetLanguage string, webpageContent string) (*models.NovelDetails, error) {
+	novelDetails, _, _, err := runFallbackChain(ctx, f.chain, func(client IClient) (*models.NovelDetails, error) {
+		return client.TranslateNovelDetails(ctx, targetLanguage, webpageContent)
+	})
+	return novelDetails, err
+}
+
+func (f *fallbackClient) TranslateNovelChapter(ctx context.Context, chapterContext *models.ChapterContext, webpageContent string) (*models.TranslatedChapter, error) {
+	translatedChapter, provider, attempts, err := runFallbackChain(ctx, f.chain, func(client IClient) (*models.TranslatedChapter, error) {
+		return client.TranslateNovelChapter(ctx, chapterContext, webpageContent)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	translatedChapter.Provider = provider
+	translatedChapter.Attempts = attempts
+	return translatedChapter, nil
+}
+
+// runFallbackChain calls each provider of the chain in order and returns the first successful result,
+// together with the provider that produced it and the number of attempts it took
+func runFallbackChain[T any](ctx context.Context, chain []string, call func(client IClient) (*T, error)) (*T, string, int, error) {
+	var lastErr error
+	attempts := 0
+
+	for _, provider := range chain {
+		if err := ctx.Err(); err != nil {
+			return nil, "", attempts, err
+		}
+
+		client, err := GetClient(provider)
+		if err != nil {
+			return nil, "", attempts, err
+		}
+
+		attempts++
+		result, err := call(client)
+		if err == nil {
+			return result, provider, attempts, nil
+		}
+
+		kind := ClassifyError(err)
+		log.Printf("Provider %s failed on attempt %d (%s): %v", provider, attempts, kind, err)
+		if kind == ErrorKindFatal {
+			return nil, provider, attempts, fmt.Errorf("provider %s failed: %w", provider, err)
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return nil, "", attempts, fmt.Errorf("fallback chain is empty")
+	}
+	return nil, "", attempts, fmt.Errorf("all providers in the fallback chain failed: %w", lastErr)
+}
@@ -2,7 +2,7 @@ package llm
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"log"
 
 	"backend/models"
@@ -19,27 +19,52 @@ type geminiClientImpl struct {
 	geminiClient *genai.Client
 }
 
-func (g geminiClientImpl) TranslateNovelDetails(ctx context.Context, webpageContent string) (*models.NovelDetails, error) {
-	prompt := `
-	You are a professional translator for webnovels.
-	Please extract and translate information from this novel page content.
-
-	Page content: ` + webpageContent + `
-
-	Return ONLY a valid JSON object with this exact structure:
-	{
-		"novel_title_original": "Original title in the source language",
-		"novel_title_translated": "Translated title in English",
-		"novel_summary_translated": "Translated summary in English in HTML format with paragraph tags. Please ensure that the summary has valid HTML tags for rendering on the frontend.",
-		"possible_novel_genres": ["Genre1", "Genre2", ...],
-		"number_of_chapters": "total number of chapters in original as integer",
-		"status": "Ongoing" or "Completed" or "Unknown"
+// checkGeminiResponse makes sure the prompt was not blocked and a complete candidate was returned
+func checkGeminiResponse(response *genai.GenerateContentResponse) error {
+	if response.PromptFeedback != nil && response.PromptFeedback.BlockReason != "" {
+		return fmt.Errorf("%w: gemini blocked the prompt with reason %s", ErrContentRefused, response.PromptFeedback.BlockReason)
 	}
+	if len(response.Candidates) == 0 {
+		return fmt.Errorf("%w: gemini returned no candidates", ErrInvalidResponse)
+	}
+
+	switch response.Candidates[0].FinishReason {
+	case genai.FinishReasonSafety, genai.FinishReasonProhibitedContent, genai.FinishReasonBlocklist, genai.FinishReasonSPII:
+		return fmt.Errorf("%w: gemini stopped with reason %s", ErrContentRefused, response.Candidates[0].FinishReason)
+	}
+	if response.Candidates[0].FinishReason == genai.FinishReasonMaxTokens {
+		return fmt.Errorf("%w: gemini stopped with reason %s", ErrTruncatedResponse, response.Candidates[0].FinishReason)
+	}
+	return nil
+}
+
+// recordGeminiUsage records the tokens reported in the usage metadata of a Gemini response
+func recordGeminiUsage(ctx context.Context, response *genai.GenerateContentResponse) {
+	if response.UsageMetadata == nil {
+		return
+	}
+
+	model := response.ModelVersion
+	if model == "" {
+		model = modelOrDefault(ctx, GenerateContentModel)
+	}
+
+	usage := response.UsageMetadata
+	recordUsage(ctx, ProviderGemini, model,
+		int(usage.PromptTokenCount-usage.CachedContentTokenCount),
+		int(usage.CandidatesTokenCount+usage.ThoughtsTokenCount),
+		int(usage.CachedContentTokenCount),
+		0)
+}
 
-	Do not include any commentary, explanation, or preamble. Only return the JSON object.`
+func (g geminiClientImpl) TranslateNovelDetails(ctx context.Context, targetLanguage string, webpageContent string) (*models.NovelDetails, error) {
+	prompt, err := novelDetailsPrompt(targetLanguage, webpageContent)
+	if err != nil {
+		return nil, err
+	}
 
 	response, err := g.geminiClient.Models.GenerateContent(ctx,
-		GenerateContentModel,
+		modelOrDefault(ctx, GenerateContentModel),
 		[]*genai.Content{genai.NewContentFromText(prompt, genai.RoleUser)},
 		&genai.GenerateContentConfig{
 			Temperature:      genai.Ptr(float32(0.3)),
@@ -55,12 +80,12 @@ func (g geminiClientImpl) TranslateNovelDetails(ctx context.Context, webpageCont
 					},
 					"novel_title_translated": {
 						Type:        "string",
-						Description: "Translated title in English",
+						Description: "Translated title in " + targetLanguageOrDefault(targetLanguage),
 						Nullable:    genai.Ptr(false),
 					},
 					"novel_summary_translated": {
 						Type:        "string",
-						Description: "Translated summary in English in HTML format with paragraph tags. Please ensure that the summary has valid HTML tags for rendering on the frontend.",
+						Description: "Translated summary in " + targetLanguageOrDefault(targetLanguage) + " in HTML format with paragraph tags. Please ensure that the summary has valid HTML tags for rendering on the frontend.",
 						Nullable:    genai.Ptr(false),
 					},
 					"possible_novel_genres": {
@@ -89,43 +114,25 @@ func (g geminiClientImpl) TranslateNovelDetails(ctx context.Context, webpageCont
 		return nil, err
 	}
 
-	log.Println(response.Text())
+	recordGeminiUsage(ctx, response)
 
-	var novelDetails models.NovelDetails
-	if err = json.Unmarshal([]byte(response.Text()), &novelDetails); err != nil {
+	if err = checkGeminiResponse(response); err != nil {
 		return nil, err
 	}
 
-	return &novelDetails, nil
+	log.Println(response.Text())
+
+	return ParseNovelDetails(response.Text())
 }
 
-func (g geminiClientImpl) TranslateNovelChapter(ctx context.Context, novelGenres []string, webpageContent string) (*models.TranslatedChapter, error) {
-	prompt := `
-        You are the best webnovel translator and editor, capable of producing the highest quality work.
-		Your task is translating and polishing the following Webnovel chapter into flawless English, ensuring perfect grammar and language. Translate all original language object names, including places, abilities, techniques, and other cultural references, into English.
-		Your goal is to craft an engaging English version while preserving the original content. The author of the original text may have limited writing skills, so take the liberty to polish the content in your translation wherever you judge necessary. Pay special attention to the dialogues, ensuring they flow smoothly and sound lifelike. Do not add anything extra anywhere from your end.
-		Finally, you mustn't lose any content from the original during the translation process. 
-		I trust you to provide the best possible results. Please translate the full chapter as per these guidelines.
-
-        Currently known novel genres: ` + models.GenresToString(novelGenres) + `
-		Chapter Content in the source language: ` + webpageContent + `
-
-        Return ONLY a valid JSON object with this exact structure:
-        {{
-            "translated_chapter_title": "The translated title of the chapter. This is important. Never miss it.",
-			"original_chapter_title": "The original title in the source language.",
-            "translated_chapter_contents": "The translated content of the full chapter in HTML format with paragraph tags. Please ensure that the chapter content has valid HTML tags for rendering on the frontend. And, most importantly, ensure that the full chapter content is included in the response.",
-            "possible_new_genres": "Array of any genres you detect that aren't already known (leave empty if none). Response should be like this ["Genre1", "Genre2", ...]"
-        }}
-	
-		Additional instructions. Please follow these instructions carefully:
-		- Please terminate the translation and return when the chapter ends.
-		- Do not miss any of the fields. This is very important. 
-		- Do not include any commentary, explanation, or preamble. Only return the JSON object.
-	`
+func (g geminiClientImpl) TranslateNovelChapter(ctx context.Context, chapterContext *models.ChapterContext, webpageContent string) (*models.TranslatedChapter, error) {
+	prompt, err := chapterPrompt(chapterContext, webpageContent)
+	if err != nil {
+		return nil, err
+	}
 
 	response, err := g.geminiClient.Models.GenerateContent(ctx,
-		GenerateContentModel,
+		modelOrDefault(ctx, GenerateContentModel),
 		[]*genai.Content{genai.NewContentFromText(prompt, genai.RoleUser)},
 		&genai.GenerateContentConfig{
 			Temperature:      genai.Ptr(float32(0.3)),
@@ -157,6 +164,33 @@ func (g geminiClientImpl) TranslateNovelChapter(ctx context.Context, novelGenres
 						},
 						Nullable: genai.Ptr(false),
 					},
+					"new_glossary_terms": {
+						Type:        "array",
+						Description: "Proper nouns (character names, places, sects, techniques, items, titles) that appear in this chapter and are not in the glossary, each with the translation you chose (leave empty if none).",
+						Items: &genai.Schema{
+							Type: "object",
+							Properties: map[string]*genai.Schema{
+								"original": {
+									Type:        "string",
+									Description: "The term in the source language.",
+								},
+								"translated": {
+									Type:        "string",
+									Description: "The translation used for the term.",
+								},
+								"category": {
+									Type:        "string",
+									Description: "One of 'character', 'place', 'sect', 'technique', 'item', 'title' or 'other'.",
+								},
+							},
+						},
+						Nullable: genai.Ptr(false),
+					},
+					"updated_story_summary": {
+						Type:        "string",
+						Description: "An updated summary of the story so far that merges the story so far given in the prompt with the events of this chapter, in under 400 words.",
+						Nullable:    genai.Ptr(false),
+					},
 				},
 			},
 		},
@@ -165,12 +199,13 @@ func (g geminiClientImpl) TranslateNovelChapter(ctx context.Context, novelGenres
 		return nil, err
 	}
 
-	log.Println(response.Text())
+	recordGeminiUsage(ctx, response)
 
-	var translatedChapter models.TranslatedChapter
-	if err = json.Unmarshal([]byte(response.Text()), &translatedChapter); err != nil {
+	if err = checkGeminiResponse(response); err != nil {
 		return nil, err
 	}
 
-	return &translatedChapter, nil
+	log.Println(response.Text())
+
+	return ParseTranslatedChapter(response.Text(), chapterContext)
 }
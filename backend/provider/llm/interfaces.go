@@ -2,25 +2,46 @@ package llm
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"os"
+	"sort"
+	"strings"
 
 	"backend/models"
 
 	awsConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
-	"github.com/sashabaranov/go-openai"
 	"google.golang.org/genai"
 )
 
+const (
+	ProviderClaude = "claude"
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
+
+	// ProviderFake returns deterministic translations without any network call, for offline development
+	ProviderFake = "fake"
+
+	// ProviderFallback tries the providers configured in LLM_FALLBACK_CHAIN in order
+	ProviderFallback = "fallback"
+)
+
 var (
 	geminiClient IClient
 	openaiClient IClient
 	claudeClient IClient
+
+	// providers maps a provider name to its client
+	providers = map[string]IClient{}
+
+	// defaultProvider is used whenever a request does not ask for a specific provider
+	defaultProvider = ProviderClaude
 )
 
 type IClient interface {
-	TranslateNovelDetails(ctx context.Context, webpageContent string) (*models.NovelDetails, error)
-	TranslateNovelChapter(ctx context.Context, novelGenres []string, webpageContent string) (*models.TranslatedChapter, error)
+	TranslateNovelDetails(ctx context.Context, targetLanguage string, webpageContent string) (*models.NovelDetails, error)
+	TranslateNovelChapter(ctx context.Context, chapterContext *models.ChapterContext, webpageContent string) (*models.TranslatedChapter, error)
 }
 
 func init() {
@@ -29,18 +50,29 @@ func init() {
 		Backend: genai.BackendGeminiAPI,
 	})
 	if err != nil {
-		panic("Failed to create Gemini client: " + err.Error())
+		// Keep the other providers usable without Gemini credentials
+		log.Printf("Warning: Gemini provider unavailable: %v", err)
+		geminiClient = &unavailableClient{provider: ProviderGemini, err: err}
+	} else {
+		geminiClient = &geminiClientImpl{
+			geminiClient: genaiClient,
+		}
 	}
 
-	geminiClient = &geminiClientImpl{
-		geminiClient: genaiClient,
+	openaiModelName := openaiModel
+	if model := os.Getenv("OPENAI_MODEL"); model != "" {
+		openaiModelName = model
 	}
 
-	config := openai.DefaultConfig(os.Getenv("OPENAI_API_KEY"))
-	config.BaseURL = os.Getenv("OPENAI_API_BASE_URL")
-
-	openaiClient = &openaiClientImpl{
-		openaiClient: openai.NewClientWithConfig(config),
+	openaiClient, err = NewOpenAICompatibleClient(OpenAICompatibleConfig{
+		Name:       ProviderOpenAI,
+		BaseURL:    os.Getenv("OPENAI_API_BASE_URL"),
+		APIKeyEnv:  "OPENAI_API_KEY",
+		Model:      openaiModelName,
+		JSONSchema: true,
+	})
+	if err != nil {
+		panic("Failed to create OpenAI client: " + err.Error())
 	}
 
 	cfg, _ := awsConfig.LoadDefaultConfig(context.Background(), awsConfig.WithRegion("us-east-1"))
@@ -49,6 +81,99 @@ func init() {
 	claudeClient = &claudeClientImpl{
 		claudeClient: client,
 	}
+
+	Register(ProviderClaude, NewCachingClient(ProviderClaude, claudeModelOrProfile, claudeClient))
+	Register(ProviderGemini, NewCachingClient(ProviderGemini, GenerateContentModel, geminiClient))
+	Register(ProviderOpenAI, NewCachingClient(ProviderOpenAI, openaiModelName, openaiClient))
+	// The fake provider is not cached so that its scripted behaviors play on every run
+	Register(ProviderFake, newFakeClientFromEnv())
+
+	// Additional OpenAI-compatible providers, e.g. local llama.cpp, Ollama or vLLM servers,
+	// are configured in the JSON file pointed to by LLM_OPENAI_COMPATIBLE_CONFIG
+	compatibleConfigs, err := loadOpenAICompatibleConfigs()
+	if err != nil {
+		panic("Failed to load OpenAI-compatible providers: " + err.Error())
+	}
+	for _, compatibleConfig := range compatibleConfigs {
+		if _, ok := providers[compatibleConfig.Name]; ok || compatibleConfig.Name == ProviderFallback {
+			panic("Duplicate LLM provider configured in LLM_OPENAI_COMPATIBLE_CONFIG: " + compatibleConfig.Name)
+		}
+
+		compatibleClient, err := NewOpenAICompatibleClient(compatibleConfig)
+		if err != nil {
+			panic("Failed to create OpenAI-compatible client: " + err.Error())
+		}
+		Register(compatibleConfig.Name, NewCachingClient(compatibleConfig.Name, compatibleConfig.Model, compatibleClient))
+	}
+
+	// The fallback chain can be configured through the LLM_FALLBACK_CHAIN environment variable, e.g. "claude,gemini,openai"
+	chain := []string{ProviderClaude, ProviderGemini, ProviderOpenAI}
+	if value := os.Getenv("LLM_FALLBACK_CHAIN"); value != "" {
+		chain = nil
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := providers[name]; !ok {
+				panic("Unknown LLM provider configured in LLM_FALLBACK_CHAIN: " + name)
+			}
+			chain = append(chain, name)
+		}
+	}
+	Register(ProviderFallback, NewFallbackClient(chain))
+
+	// The default provider can be overridden through the LLM_PROVIDER environment variable
+	if name := os.Getenv("LLM_PROVIDER"); name != "" {
+		if _, ok := providers[name]; !ok {
+			panic("Unknown LLM provider configured in LLM_PROVIDER: " + name)
+		}
+		defaultProvider = name
+	}
+}
+
+// Register adds a client to the provider registry under the given name
+func Register(name string, client IClient) {
+	providers[name] = client
+}
+
+// ProviderOrDefault returns the given provider name, or the configured default if it is empty
+func ProviderOrDefault(name string) string {
+	if name == "" {
+		return defaultProvider
+	}
+	return name
+}
+
+// GetClient returns the client registered under the given provider name.
+// An empty name resolves to the configured default provider.
+func GetClient(name string) (IClient, error) {
+	client, ok := providers[ProviderOrDefault(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return client, nil
+}
+
+// GetProviderNames returns the names of all registered providers in sorted order
+func GetProviderNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unavailableClient stands in for a provider whose client could not be created
+type unavailableClient struct {
+	provider string
+	err      error
+}
+
+func (u *unavailableClient) TranslateNovelDetails(ctx context.Context, targetLanguage string, webpageContent string) (*models.NovelDetails, error) {
+	return nil, fmt.Errorf("%w: %s: %v", ErrProviderUnavailable, u.provider, u.err)
+}
+
+func (u *unavailableClient) TranslateNovelChapter(ctx context.Context, chapterContext *models.ChapterContext, webpageContent string) (*models.TranslatedChapter, error) {
+	return nil, fmt.Errorf("%w: %s: %v", ErrProviderUnavailable, u.provider, u.err)
 }
 
 func GetGemini() IClient {
@@ -0,0 +1,22 @@
+package llm
+
+import "context"
+
+type modelOverrideKey struct{}
+
+// WithModel returns a context whose LLM calls use the model instead of the one configured for the provider.
+// An empty model keeps the configured one.
+func WithModel(ctx context.Context, model string) context.Context {
+	if model == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, modelOverrideKey{}, model)
+}
+
+// modelOrDefault returns the model requested with WithModel, or the configured model when none is requested
+func modelOrDefault(ctx context.Context, configured string) string {
+	if model, _ := ctx.Value(modelOverrideKey{}).(string); model != "" {
+		return model
+	}
+	return configured
+}
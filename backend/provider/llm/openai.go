@@ -3,113 +3,210 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os"
 
 	"backend/models"
 
 	"github.com/sashabaranov/go-openai"
 )
 
+// openaiModel is the default model of the OpenAI provider, overridable with OPENAI_MODEL
+const openaiModel = "gpt-5"
+
+// OpenAICompatibleConfig configures a client for any server implementing the OpenAI chat completions API,
+// such as OpenAI itself or a local llama.cpp, Ollama or vLLM server
+type OpenAICompatibleConfig struct {
+	// Name is the provider name the client is registered under
+	Name    string `json:"name"`
+	BaseURL string `json:"base_url"`
+
+	// APIKeyEnv is the environment variable holding the API key. Local servers usually need none.
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+
+	Model string `json:"model"`
+
+	// Temperature, when set, overrides the default temperature of the server
+	Temperature float32 `json:"temperature,omitempty"`
+
+	// MaxTokens, when set, limits the tokens generated per response (sent as max_tokens)
+	MaxTokens int `json:"max_tokens,omitempty"`
+
+	// JSONMode asks the server to only return a valid JSON object
+	JSONMode bool `json:"json_mode,omitempty"`
+
+	// JSONSchema asks the server to return a JSON object following the schema of the expected response.
+	// It takes precedence over JSONMode.
+	JSONSchema bool `json:"json_schema,omitempty"`
+}
+
 type openaiClientImpl struct {
 	openaiClient *openai.Client
+
+	name        string
+	model       string
+	temperature float32
+	maxTokens   int
+	jsonMode    bool
+	jsonSchema  bool
 }
 
-func (c openaiClientImpl) TranslateNovelDetails(ctx context.Context, webpageContent string) (*models.NovelDetails, error) {
-	prompt := `
-	You are a professional translator for webnovels.
-	Please extract and translate information from this novel page content.
-
-	Page content: ` + webpageContent + `
-
-	Return ONLY a valid JSON object with this exact structure:
-	{
-		"novel_title_original": "Original title in the source language",
-		"novel_title_translated": "Translated title in English",
-		"novel_summary_translated": "Translated summary in English in HTML format with paragraph tags. Please ensure that the summary has valid HTML tags for rendering on the frontend.",
-		"possible_novel_genres": ["Genre1", "Genre2", ...],
-		"number_of_chapters": "total number of chapters in original as integer",
-		"status": "Ongoing" or "Completed" or "Unknown"
-	}
-
-	Do not include any commentary, explanation, or preamble. Only return the JSON object.`
-
-	resp, err := c.openaiClient.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: "gpt-5",
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
-			},
-		},
-	)
-	if err != nil {
-		log.Println(ctx, "CreateChatCompletion err: %v", err)
-		return nil, fmt.Errorf("failed to create chat completion: %w", err)
+// NewOpenAICompatibleClient creates a client for the OpenAI-compatible server described by the config
+func NewOpenAICompatibleClient(cfg OpenAICompatibleConfig) (IClient, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("OpenAI-compatible provider name cannot be empty")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("model of OpenAI-compatible provider %s cannot be empty", cfg.Name)
 	}
 
-	log.Println(resp.Choices[0].Message.Content)
+	apiKey := ""
+	if cfg.APIKeyEnv != "" {
+		apiKey = os.Getenv(cfg.APIKeyEnv)
+	}
 
-	var novelDetails models.NovelDetails
-	if err = json.Unmarshal([]byte(resp.Choices[0].Message.Content), &novelDetails); err != nil {
-		log.Println(ctx, "Unmarshal err: %v", err)
+	config := openai.DefaultConfig(apiKey)
+	if cfg.BaseURL != "" {
+		config.BaseURL = cfg.BaseURL
+	}
+
+	return &openaiClientImpl{
+		openaiClient: openai.NewClientWithConfig(config),
+		name:         cfg.Name,
+		model:        cfg.Model,
+		temperature:  cfg.Temperature,
+		maxTokens:    cfg.MaxTokens,
+		jsonMode:     cfg.JSONMode,
+		jsonSchema:   cfg.JSONSchema,
+	}, nil
+}
+
+// loadOpenAICompatibleConfigs reads the OpenAI-compatible providers from the JSON file pointed to by
+// the LLM_OPENAI_COMPATIBLE_CONFIG environment variable
+func loadOpenAICompatibleConfigs() ([]OpenAICompatibleConfig, error) {
+	path := os.Getenv("LLM_OPENAI_COMPATIBLE_CONFIG")
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
 		return nil, err
 	}
 
-	return &novelDetails, nil
+	var configs []OpenAICompatibleConfig
+	if err = json.Unmarshal(content, &configs); err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+// checkOpenAIResponse makes sure a complete choice was returned and it was not filtered
+func checkOpenAIResponse(resp *openai.ChatCompletionResponse) error {
+	if len(resp.Choices) == 0 {
+		return fmt.Errorf("%w: openai returned no choices", ErrInvalidResponse)
+	}
+	if resp.Choices[0].FinishReason == openai.FinishReasonContentFilter {
+		return fmt.Errorf("%w: openai stopped with reason %s", ErrContentRefused, resp.Choices[0].FinishReason)
+	}
+	if resp.Choices[0].FinishReason == openai.FinishReasonLength {
+		return fmt.Errorf("%w: openai stopped with reason %s", ErrTruncatedResponse, resp.Choices[0].FinishReason)
+	}
+	return nil
 }
 
-func (c openaiClientImpl) TranslateNovelChapter(ctx context.Context, novelGenres []string, webpageContent string) (*models.TranslatedChapter, error) {
-	prompt := `
-        You are the best webnovel translator and editor, capable of producing the highest quality work.
-		Your task is translating and polishing the following Webnovel chapter into flawless English, ensuring perfect grammar and language. Translate all original language object names, including places, abilities, techniques, and other cultural references, into English.
-		Your goal is to craft an engaging English version while preserving the original content. The author of the original text may have limited writing skills, so take the liberty to polish the content in your translation wherever you judge necessary. Pay special attention to the dialogues, ensuring they flow smoothly and sound lifelike. Do not add anything extra anywhere from your end.
-		Finally, you mustn't lose any content from the original during the translation process. 
-		I trust you to provide the best possible results. Please translate the full chapter as per these guidelines.
-
-        Currently known novel genres: ` + models.GenresToString(novelGenres) + `
-		Chapter Content in the source language: ` + webpageContent + `
-
-        Return ONLY a valid JSON object with this exact structure:
-        {{
-            "translated_chapter_title": "The translated title of the chapter.",
-			"original_chapter_title": "The original title in the source language.",
-            "translated_chapter_contents": "The translated content of the full chapter in HTML format with paragraph tags. Please ensure that the chapter content has valid HTML tags for rendering on the frontend. And, most importantly, ensure that the full chapter content is included in the response.",
-            "possible_new_genres": "Array of any genres you detect that aren't already known (leave empty if none). Response should be like this ["Genre1", "Genre2", ...]"
-        }}
-	
-		Additional instructions. Please follow these instructions carefully:
-		- Please terminate the translation and return when the chapter ends.
-		- Do not miss any of the fields. This is very important. 
-		- Do not include any commentary, explanation, or preamble. Only return the JSON object.
-	`
-	resp, err := c.openaiClient.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: "gpt-5",
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
+// recordOpenAIUsage records the tokens reported in the usage of an OpenAI response
+func (c openaiClientImpl) recordOpenAIUsage(ctx context.Context, resp *openai.ChatCompletionResponse) {
+	cachedTokens := 0
+	if resp.Usage.PromptTokensDetails != nil {
+		cachedTokens = resp.Usage.PromptTokensDetails.CachedTokens
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = modelOrDefault(ctx, c.model)
+	}
+
+	recordUsage(ctx, c.name, model,
+		resp.Usage.PromptTokens-cachedTokens,
+		resp.Usage.CompletionTokens,
+		cachedTokens,
+		0)
+}
+
+// complete sends the prompt as a single user message and returns the content of the answer,
+// constrained to the named schema when the client uses JSON schemas
+func (c openaiClientImpl) complete(ctx context.Context, prompt, schemaName string, schema json.RawMessage) (string, error) {
+	request := openai.ChatCompletionRequest{
+		Model: modelOrDefault(ctx, c.model),
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
 			},
 		},
-	)
+		Temperature: c.temperature,
+		MaxTokens:   c.maxTokens,
+	}
+	if c.jsonSchema {
+		request.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   schemaName,
+				Schema: schema,
+				Strict: true,
+			},
+		}
+	} else if c.jsonMode {
+		request.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		}
+	}
+
+	resp, err := c.openaiClient.CreateChatCompletion(ctx, request)
 	if err != nil {
-		log.Println(ctx, "CreateChatCompletion err: %v", err)
-		return nil, fmt.Errorf("failed to create chat completion: %w", err)
+		log.Printf("CreateChatCompletion err: %v", err)
+		return "", fmt.Errorf("failed to create chat completion: %w", err)
+	}
+
+	c.recordOpenAIUsage(ctx, &resp)
+
+	if err = checkOpenAIResponse(&resp); err != nil {
+		return "", err
 	}
 
 	log.Println(resp.Choices[0].Message.Content)
 
-	var translatedChapter models.TranslatedChapter
-	if err = json.Unmarshal([]byte(resp.Choices[0].Message.Content), &translatedChapter); err != nil {
-		log.Println(ctx, "Unmarshal err: %v", err)
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (c openaiClientImpl) TranslateNovelDetails(ctx context.Context, targetLanguage string, webpageContent string) (*models.NovelDetails, error) {
+	prompt, err := novelDetailsPrompt(targetLanguage, webpageContent)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := c.complete(ctx, prompt, "novel_details", novelDetailsSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseNovelDetails(content)
+}
+
+func (c openaiClientImpl) TranslateNovelChapter(ctx context.Context, chapterContext *models.ChapterContext, webpageContent string) (*models.TranslatedChapter, error) {
+	prompt, err := chapterPrompt(chapterContext, webpageContent)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := c.complete(ctx, prompt, "translated_chapter", translatedChapterSchema)
+	if err != nil {
 		return nil, err
 	}
 
-	return &translatedChapter, nil
+	return ParseTranslatedChapter(content, chapterContext)
 }
@@ -0,0 +1,299 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"backend/models"
+)
+
+var (
+	jsonObjectRegex   = regexp.MustCompile(`(?s){.*}`)
+	chapterCountRegex = regexp.MustCompile(`\d[\d,]*`)
+)
+
+// ValidationError is returned when a provider response parses but misses a required value.
+// It wraps ErrInvalidResponse, so it is retryable.
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v: %s %s", ErrInvalidResponse, e.Field, e.Reason)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidResponse
+}
+
+// ParseNovelDetails parses and validates the novel details returned by any provider.
+// The chapter count is accepted as a number or as a string containing one.
+func ParseNovelDetails(content string) (*models.NovelDetails, error) {
+	var raw struct {
+		models.NovelDetails
+		NumberOfChapters json.RawMessage `json:"number_of_chapters"`
+	}
+	if err := tryParseJSON(content, &raw); err != nil {
+		return nil, err
+	}
+
+	novelDetails := raw.NovelDetails
+	chapterCount, err := parseChapterCount(raw.NumberOfChapters)
+	if err != nil {
+		return nil, err
+	}
+	novelDetails.NumberOfChapters = chapterCount
+
+	if strings.TrimSpace(novelDetails.NovelTitleTranslated) == "" {
+		return nil, &ValidationError{Field: "novel_title_translated", Reason: "is empty"}
+	}
+
+	return &novelDetails, nil
+}
+
+// ParseTranslatedChapter parses and validates the chapter translation returned by any provider.
+// Only the first part of a chapter translated in several parts must have a title.
+func ParseTranslatedChapter(content string, chapterContext *models.ChapterContext) (*models.TranslatedChapter, error) {
+	var translatedChapter models.TranslatedChapter
+	if err := tryParseJSON(content, &translatedChapter); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(translatedChapter.TranslatedChapterContents) == "" {
+		return nil, &ValidationError{Field: "translated_chapter_contents", Reason: "is empty"}
+	}
+	if strings.TrimSpace(translatedChapter.TranslatedChapterTitle) == "" && (chapterContext == nil || chapterContext.Part <= 1) {
+		return nil, &ValidationError{Field: "translated_chapter_title", Reason: "is empty"}
+	}
+
+	return &translatedChapter, nil
+}
+
+// parseChapterCount reads a chapter count given as a JSON number or as a string like "1,234 chapters".
+// A missing or unknown count is 0.
+func parseChapterCount(raw json.RawMessage) (int, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, nil
+	}
+
+	var number float64
+	if err := json.Unmarshal(raw, &number); err == nil {
+		if number < 0 {
+			return 0, &ValidationError{Field: "number_of_chapters", Reason: "is negative"}
+		}
+		return int(number), nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return 0, &ValidationError{Field: "number_of_chapters", Reason: "is neither a number nor a string"}
+	}
+
+	match := chapterCountRegex.FindString(text)
+	if match == "" {
+		return 0, nil
+	}
+	count, err := strconv.Atoi(strings.ReplaceAll(match, ",", ""))
+	if err != nil {
+		return 0, &ValidationError{Field: "number_of_chapters", Reason: "is not a valid integer"}
+	}
+	return count, nil
+}
+
+// tryParseJSON parses the JSON object of a response, repairing common damage when it does not parse as-is:
+// Markdown code blocks, text around the object, unescaped quotes in dialogues, raw line breaks in strings and trailing commas
+func tryParseJSON[T any](content string, target *T) error {
+	jsonStr := cleanJSON(content)
+
+	// First, try parsing as-is
+	err := json.Unmarshal([]byte(jsonStr), target)
+	if err == nil {
+		return nil
+	}
+
+	// If that fails, try fixing unescaped quotes, control characters and trailing commas
+	fixedJSON := repairJSON(fixUnescapedQuotesInJSON(jsonStr))
+	err2 := json.Unmarshal([]byte(fixedJSON), target)
+	if err2 == nil {
+		return nil
+	}
+
+	// Return the original error if both attempts fail
+	return fmt.Errorf("%w: failed to parse JSON (original error: %v, fixed attempt error: %v)", ErrInvalidResponse, err, err2)
+}
+
+// cleanJSON trims whitespace, removes markdown/code block markers, and attempts to extract the first JSON object from the string.
+func cleanJSON(s string) string {
+	s = strings.TrimSpace(s)
+	// Remove Markdown code block markers if present
+	s = strings.TrimPrefix(s, "```json")
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimSuffix(s, "```")
+	s = strings.TrimSpace(s)
+	// Try to extract the first JSON object using regex
+	match := jsonObjectRegex.FindString(s)
+	if match != "" {
+		return match
+	}
+	return s
+}
+
+// fixUnescapedQuotesInJSON attempts to fix unescaped quotes within JSON string values.
+// This is needed when LLMs produce JSON with dialogue containing unescaped quotes.
+func fixUnescapedQuotesInJSON(s string) string {
+	var result strings.Builder
+	inString := false
+	escaped := false
+	stringStart := -1
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+
+		if escaped {
+			result.WriteRune(c)
+			escaped = false
+			continue
+		}
+
+		if c == '\\' {
+			result.WriteRune(c)
+			escaped = true
+			continue
+		}
+
+		if c == '"' {
+			if !inString {
+				// Starting a string
+				inString = true
+				stringStart = i
+				result.WriteRune(c)
+			} else {
+				// Potentially ending a string - but we need to check if this is a legitimate end
+				// Look ahead to see if next non-whitespace char is :, ,, }, or ]
+				isLegitEnd := false
+				for j := i + 1; j < len(runes); j++ {
+					nextChar := runes[j]
+					if nextChar == ' ' || nextChar == '\t' || nextChar == '\n' || nextChar == '\r' {
+						continue
+					}
+					if nextChar == ':' || nextChar == ',' || nextChar == '}' || nextChar == ']' {
+						isLegitEnd = true
+					}
+					break
+				}
+
+				// Also check if this might be starting a new key (look for pattern: "key":)
+				// by checking if there's text before the quote that looks like a value ending
+				if !isLegitEnd {
+					// Look for pattern where quote is followed by text then colon (new key)
+					// This handles cases like: value" , "newKey":
+					for j := i + 1; j < len(runes); j++ {
+						nextChar := runes[j]
+						if nextChar == ' ' || nextChar == '\t' || nextChar == '\n' || nextChar == '\r' {
+							continue
+						}
+						// If we see a letter/underscore, this might be continuing content or a new key
+						// Check further ahead for colon to determine
+						if (nextChar >= 'a' && nextChar <= 'z') || (nextChar >= 'A' && nextChar <= 'Z') || nextChar == '_' {
+							// Scan for quote-colon pattern to determine if this is a new key
+							foundQuoteColon := false
+							for k := j; k < len(runes); k++ {
+								if runes[k] == '"' {
+									// Check if pattern is "key":
+									for l := k + 1; l < len(runes); l++ {
+										if runes[l] == ' ' || runes[l] == '\t' {
+											continue
+										}
+										if runes[l] == ':' {
+											foundQuoteColon = true
+										}
+										break
+									}
+									break
+								}
+								if runes[k] == ',' || runes[k] == '}' || runes[k] == ']' {
+									break
+								}
+							}
+							if foundQuoteColon && stringStart > 0 {
+								// This is likely dialogue - the quote should be escaped
+								result.WriteString("\\\"")
+								inString = true // stay in string
+								continue
+							}
+						}
+						break
+					}
+				}
+
+				if isLegitEnd {
+					inString = false
+					stringStart = -1
+				} else {
+					// This quote appears to be inside dialogue - escape it
+					result.WriteRune('\\')
+				}
+				result.WriteRune(c)
+			}
+		} else {
+			result.WriteRune(c)
+		}
+	}
+
+	return result.String()
+}
+
+// repairJSON escapes raw line breaks and tabs inside strings and drops trailing commas before } and ]
+func repairJSON(s string) string {
+	var result strings.Builder
+	inString := false
+	escaped := false
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			case c == '\n':
+				result.WriteString(`\n`)
+				continue
+			case c == '\r':
+				result.WriteString(`\r`)
+				continue
+			case c == '\t':
+				result.WriteString(`\t`)
+				continue
+			}
+			result.WriteRune(c)
+			continue
+		}
+
+		if c == '"' {
+			inString = true
+		} else if c == ',' {
+			// Drop the comma if only whitespace separates it from a closing bracket
+			j := i + 1
+			for j < len(runes) && strings.ContainsRune(" \t\r\n", runes[j]) {
+				j++
+			}
+			if j < len(runes) && (runes[j] == '}' || runes[j] == ']') {
+				continue
+			}
+		}
+		result.WriteRune(c)
+	}
+
+	return result.String()
+}
@@ -0,0 +1,193 @@
+package llm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+
+	"backend/models"
+)
+
+// promptTemplateSource is the source of the templates shipped with the binary
+const promptTemplateSource = "embedded"
+
+//go:embed prompts/*.tmpl
+var embeddedPrompts embed.FS
+
+// promptTemplate is a loaded prompt template. Its version changes whenever its text does.
+type promptTemplate struct {
+	name     string
+	version  string
+	source   string
+	text     string
+	template *template.Template
+}
+
+// promptData holds the variables available to the prompt templates
+type promptData struct {
+	// TargetLanguage is the language to translate into
+	TargetLanguage string
+
+	// Genres is the JSON array of the known genres of the novel
+	Genres string
+
+	// Glossary lists the glossary terms of the novel, one "original => translation" per line
+	Glossary string
+
+	// Style is the instructions of the style preset of the translation
+	Style string
+
+	// StorySummary and PreviousChapterTail give the story so far
+	StorySummary        string
+	PreviousChapterTail string
+
+	// Part and TotalParts locate the content in a chapter translated in several parts
+	Part       int
+	TotalParts int
+
+	// SummarizePartOnly asks for the events of the part only as the updated story summary
+	SummarizePartOnly bool
+
+	// QualityFeedback explains why a previous translation was rejected
+	QualityFeedback string
+
+	// Content is the page to translate, empty when it is sent separately from the prompt
+	Content string
+}
+
+// promptTemplates maps the operations to their prompt template
+var promptTemplates = map[string]*promptTemplate{}
+
+// LoadPromptTemplates loads the prompt templates, and must be called before any prompt is rendered.
+// Templates in the PROMPT_TEMPLATES_DIR directory override the embedded ones with the same file name,
+// so that prompts can be changed without recompiling.
+func LoadPromptTemplates() error {
+	return loadPromptTemplates(os.Getenv("PROMPT_TEMPLATES_DIR"))
+}
+
+// loadPromptTemplates loads the template of every operation, from the directory when it has one
+func loadPromptTemplates(dir string) error {
+	for _, operation := range []string{OperationNovelDetails, OperationChapter} {
+		fileName := operation + ".tmpl"
+
+		source := promptTemplateSource
+		text, err := embeddedPrompts.ReadFile("prompts/" + fileName)
+		if err != nil {
+			return err
+		}
+
+		if dir != "" {
+			path := filepath.Join(dir, fileName)
+			override, err := os.ReadFile(path)
+			if err == nil {
+				source, text = path, override
+			} else if !os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		parsed, err := template.New(operation).Option("missingkey=error").Parse(string(text))
+		if err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+
+		sum := sha256.Sum256(text)
+		promptTemplates[operation] = &promptTemplate{
+			name:     operation,
+			version:  hex.EncodeToString(sum[:4]),
+			source:   source,
+			text:     string(text),
+			template: parsed,
+		}
+	}
+	return nil
+}
+
+// PromptVersion identifies the prompt template of the operation. Responses cached for a previous version of the
+// template are not reused.
+func PromptVersion(operation string) string {
+	if promptTemplate, ok := promptTemplates[operation]; ok {
+		return promptTemplate.version
+	}
+	return ""
+}
+
+// GetPromptTemplates lists the prompt templates in use, sorted by name
+func GetPromptTemplates() []*models.PromptTemplate {
+	templates := make([]*models.PromptTemplate, 0, len(promptTemplates))
+	for _, promptTemplate := range promptTemplates {
+		templates = append(templates, &models.PromptTemplate{
+			Name:     promptTemplate.name,
+			Version:  promptTemplate.version,
+			Source:   promptTemplate.source,
+			Template: promptTemplate.text,
+		})
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates
+}
+
+// renderPrompt executes the prompt template of the operation
+func renderPrompt(operation string, data *promptData) (string, error) {
+	promptTemplate, ok := promptTemplates[operation]
+	if !ok {
+		return "", fmt.Errorf("no prompt template for %s", operation)
+	}
+
+	var prompt bytes.Buffer
+	if err := promptTemplate.template.Execute(&prompt, data); err != nil {
+		return "", fmt.Errorf("failed to render %s prompt: %w", operation, err)
+	}
+	return prompt.String(), nil
+}
+
+// novelDetailsPrompt renders the prompt extracting the novel details. The page content is left out of the prompt
+// when it is empty, for providers sending it as a separate message.
+func novelDetailsPrompt(targetLanguage, content string) (string, error) {
+	return renderPrompt(OperationNovelDetails, &promptData{
+		TargetLanguage: targetLanguageOrDefault(targetLanguage),
+		Content:        content,
+	})
+}
+
+// chapterPrompt renders the prompt translating a chapter in its context. The chapter content is left out of the
+// prompt when it is empty, for providers sending it as a separate message.
+func chapterPrompt(chapterContext *models.ChapterContext, content string) (string, error) {
+	return renderPrompt(OperationChapter, &promptData{
+		TargetLanguage:      targetLanguageOrDefault(chapterContext.TargetLanguage),
+		Genres:              models.GenresToString(chapterContext.NovelGenres),
+		Glossary:            models.GlossaryToString(chapterContext.Glossary),
+		Style:               styleOrDefault(chapterContext.StyleInstructions),
+		StorySummary:        chapterContext.StorySummary,
+		PreviousChapterTail: chapterContext.PreviousChapterTail,
+		Part:                chapterContext.Part,
+		TotalParts:          chapterContext.TotalParts,
+		SummarizePartOnly:   chapterContext.SummarizePartOnly,
+		QualityFeedback:     chapterContext.QualityFeedback,
+		Content:             content,
+	})
+}
+
+// targetLanguageOrDefault returns the language to translate into, English when none is given
+func targetLanguageOrDefault(targetLanguage string) string {
+	if targetLanguage == "" {
+		return models.LegacyLanguage
+	}
+	return targetLanguage
+}
+
+// styleOrDefault returns the instructions of the style to translate in, those of polished when none are given
+func styleOrDefault(instructions string) string {
+	if instructions != "" {
+		return instructions
+	}
+	return models.BuiltInStylePreset(models.StylePolished).Instructions
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"backend/models"
+)
+
+var (
+	// novelDetailsSchema and translatedChapterSchema constrain the structured output of the providers
+	novelDetailsSchema      = mustJSONSchema(models.NovelDetails{})
+	translatedChapterSchema = mustJSONSchema(models.TranslatedChapter{})
+)
+
+// mustJSONSchema generates the JSON schema of a struct from its json and description tags.
+// Every property is required and no other property is allowed, as strict structured outputs expect.
+func mustJSONSchema(v any) json.RawMessage {
+	schema, err := json.Marshal(jsonSchemaOf(reflect.TypeOf(v)))
+	if err != nil {
+		panic("Failed to generate JSON schema: " + err.Error())
+	}
+	return schema
+}
+
+func jsonSchemaOf(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonSchemaOf(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": jsonSchemaOf(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			property := jsonSchemaOf(field.Type)
+			if description := field.Tag.Get("description"); description != "" {
+				property["description"] = description
+			}
+			properties[name] = property
+			required = append(required, name)
+		}
+		return map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	default:
+		panic("Unsupported type in JSON schema: " + t.String())
+	}
+}
@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"backend/models"
+)
+
+// Operations recorded with the usage of each LLM call
+const (
+	OperationNovelDetails = "novel_details"
+	OperationChapter      = "chapter"
+)
+
+// UsageRecorder is called with the usage of every LLM call
+type UsageRecorder func(ctx context.Context, usage *models.LLMUsage)
+
+// ModelPrice is the price of a model in USD per million tokens
+type ModelPrice struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+}
+
+type usageLabelsKey struct{}
+
+type usageLabels struct {
+	novelID       string
+	chapterNumber int
+	operation     string
+}
+
+var (
+	usageRecorder UsageRecorder
+
+	// priceTable maps a model name prefix to its price. It can be replaced with the
+	// JSON file pointed to by the LLM_PRICE_TABLE environment variable.
+	priceTable = map[string]ModelPrice{
+		"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+		"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		"claude-3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+		"gemini-2.5-pro":    {Input: 1.25, Output: 10, CacheRead: 0.31},
+		"gemini-2.5-flash":  {Input: 0.3, Output: 2.5, CacheRead: 0.075},
+		"gpt-5-mini":        {Input: 0.25, Output: 2, CacheRead: 0.025},
+		"gpt-5":             {Input: 1.25, Output: 10, CacheRead: 0.125},
+		"fake":              {},
+	}
+)
+
+func init() {
+	path := os.Getenv("LLM_PRICE_TABLE")
+	if path == "" {
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		panic("Failed to read LLM price table: " + err.Error())
+	}
+
+	var prices map[string]ModelPrice
+	if err = json.Unmarshal(content, &prices); err != nil {
+		panic("Failed to parse LLM price table: " + err.Error())
+	}
+	priceTable = prices
+}
+
+// SetUsageRecorder sets the function called with the usage of every LLM call
+func SetUsageRecorder(recorder UsageRecorder) {
+	usageRecorder = recorder
+}
+
+// WithUsageLabels returns a context whose LLM calls are recorded against the given novel, chapter and operation
+func WithUsageLabels(ctx context.Context, novelID string, chapterNumber int, operation string) context.Context {
+	return context.WithValue(ctx, usageLabelsKey{}, usageLabels{
+		novelID:       novelID,
+		chapterNumber: chapterNumber,
+		operation:     operation,
+	})
+}
+
+// GetModelPrice returns the price of the model, matching the longest model name prefix of the price table
+func GetModelPrice(model string) (ModelPrice, bool) {
+	var price ModelPrice
+	matched := ""
+	for prefix, p := range priceTable {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(matched) {
+			price = p
+			matched = prefix
+		}
+	}
+	return price, matched != ""
+}
+
+// recordUsage computes the cost of an LLM call and hands it to the usage recorder
+func recordUsage(ctx context.Context, provider, model string, inputTokens, outputTokens, cacheReadTokens, cacheWriteTokens int) {
+	if usageRecorder == nil {
+		return
+	}
+
+	usage := &models.LLMUsage{
+		Provider:         provider,
+		Model:            model,
+		InputTokens:      inputTokens,
+		OutputTokens:     outputTokens,
+		CacheReadTokens:  cacheReadTokens,
+		CacheWriteTokens: cacheWriteTokens,
+		Timestamp:        time.Now().Unix(),
+	}
+
+	if labels, ok := ctx.Value(usageLabelsKey{}).(usageLabels); ok {
+		usage.NovelID = labels.novelID
+		usage.ChapterNumber = labels.chapterNumber
+		usage.Operation = labels.operation
+	}
+
+	if price, ok := GetModelPrice(model); ok {
+		usage.Cost = (float64(inputTokens)*price.Input +
+			float64(outputTokens)*price.Output +
+			float64(cacheReadTokens)*price.CacheRead +
+			float64(cacheWriteTokens)*price.CacheWrite) / 1_000_000
+	} else {
+		log.Printf("No price configured for model %s, recording its usage without cost", model)
+	}
+
+	usageRecorder(ctx, usage)
+}
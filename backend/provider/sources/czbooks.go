@@ -1,9 +1,22 @@
 package sources
 
-import "strings"
+import (
+	"strings"
+
+	"backend/models"
+)
 
 type czbooks struct{}
 
+func init() {
+	Register(&models.SourceSite{
+		ID:       "czbooks",
+		Name:     "czbooks",
+		URL:      "https://czbooks.net",
+		Language: "chinese",
+	}, NewCzbooks())
+}
+
 func NewCzbooks() *czbooks {
 	return &czbooks{}
 }
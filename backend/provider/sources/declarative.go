@@ -0,0 +1,416 @@
+package sources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"backend/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+// DeclarativeSourceConfig describes a source site with selectors and regexes instead of Go code
+type DeclarativeSourceConfig struct {
+	ID       string `json:"id" yaml:"id"`
+	Name     string `json:"name" yaml:"name"`
+	BaseURL  string `json:"base_url" yaml:"base_url"`
+	Language string `json:"language" yaml:"language"`
+
+	// Mirrors are the other base URLs the site is served from
+	Mirrors []string `json:"mirrors,omitempty" yaml:"mirrors,omitempty"`
+
+	// NovelID and ChapterID are regexes matched against the novel and chapter URLs.
+	// The ID is their first capture group, or the whole match when they have none.
+	NovelID   string `json:"novel_id" yaml:"novel_id"`
+	ChapterID string `json:"chapter_id" yaml:"chapter_id"`
+
+	// ChapterURL, when the chapter URLs follow from the chapter number, is their pattern with {novel_id} and {number}
+	// standing for the novel ID and the chapter number, e.g. https://novels.example.com/book/{novel_id}/{number}.html
+	ChapterURL string `json:"chapter_url,omitempty" yaml:"chapter_url,omitempty"`
+
+	// NextChapter and Cover find the URL of the next chapter in a chapter page and of the cover in a novel page.
+	// Relative URLs are resolved against the page.
+	NextChapter *ExtractionRule `json:"next_chapter,omitempty" yaml:"next_chapter,omitempty"`
+	Cover       *ExtractionRule `json:"cover,omitempty" yaml:"cover,omitempty"`
+
+	// Title and Content, when set, narrow a chapter page down to its title and text before it is translated
+	Title   *ExtractionRule `json:"title,omitempty" yaml:"title,omitempty"`
+	Content *ExtractionRule `json:"content,omitempty" yaml:"content,omitempty"`
+
+	// TableOfContents selects the chapter links in the novel page, in reading order. Their attribute, href when none is
+	// given, is the chapter URL and their text its original title; the regex only keeps the URLs it matches.
+	// TableOfContentsNext finds the next page of the table of contents when it is split in several pages.
+	TableOfContents     *ExtractionRule `json:"table_of_contents,omitempty" yaml:"table_of_contents,omitempty"`
+	TableOfContentsNext *ExtractionRule `json:"table_of_contents_next,omitempty" yaml:"table_of_contents_next,omitempty"`
+}
+
+// ExtractionRule finds a value in a page with a CSS selector, a regex or both.
+// The selector picks the first matching element, whose attribute, or text when no attribute is given, is the value.
+// The regex is then matched against that value, or against the raw page when there is no selector, and its first
+// capture group, or whole match, is the value. Content rules keep the HTML of all the matching elements instead.
+type ExtractionRule struct {
+	Selector  string `json:"selector,omitempty" yaml:"selector,omitempty"`
+	Attribute string `json:"attribute,omitempty" yaml:"attribute,omitempty"`
+	Regex     string `json:"regex,omitempty" yaml:"regex,omitempty"`
+
+	selector cascadia.Selector
+	regex    *regexp.Regexp
+}
+
+// declarativeSource implements Source from its configuration
+type declarativeSource struct {
+	config    DeclarativeSourceConfig
+	novelID   *regexp.Regexp
+	chapterID *regexp.Regexp
+}
+
+// LoadConfiguredSources registers the sources described in the SOURCES_CONFIG file, replacing the built-in sources
+// with the same ID. It must be called once at startup, before any source is used.
+func LoadConfiguredSources() error {
+	configs, err := loadDeclarativeSourceConfigs(os.Getenv("SOURCES_CONFIG"))
+	if err != nil {
+		return err
+	}
+
+	for _, config := range configs {
+		source, err := NewDeclarativeSource(config)
+		if err != nil {
+			return err
+		}
+		if config.Name == "" {
+			config.Name = config.ID
+		}
+
+		register(&models.SourceSite{
+			ID:       config.ID,
+			Name:     config.Name,
+			URL:      config.BaseURL,
+			Mirrors:  config.Mirrors,
+			Language: config.Language,
+		}, source, true)
+	}
+	return nil
+}
+
+// loadDeclarativeSourceConfigs reads the source definitions from a YAML file, when its extension is .yaml or .yml,
+// or from a JSON file
+func loadDeclarativeSourceConfigs(path string) ([]DeclarativeSourceConfig, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []DeclarativeSourceConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &configs)
+	default:
+		err = json.Unmarshal(content, &configs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return configs, nil
+}
+
+// NewDeclarativeSource creates the source described by the config, checking its regexes and selectors
+func NewDeclarativeSource(config DeclarativeSourceConfig) (*declarativeSource, error) {
+	if config.ID == "" {
+		return nil, errors.New("source ID cannot be empty")
+	}
+
+	novelID, err := compileIDRegex(config.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("novel_id of source %s: %w", config.ID, err)
+	}
+
+	chapterID, err := compileIDRegex(config.ChapterID)
+	if err != nil {
+		return nil, fmt.Errorf("chapter_id of source %s: %w", config.ID, err)
+	}
+
+	if config.ChapterURL != "" && !strings.Contains(config.ChapterURL, ChapterNumberPlaceholder) {
+		return nil, fmt.Errorf("chapter_url of source %s: %s is required", config.ID, ChapterNumberPlaceholder)
+	}
+
+	rules := map[string]*ExtractionRule{
+		"next_chapter":           config.NextChapter,
+		"cover":                  config.Cover,
+		"title":                  config.Title,
+		"content":                config.Content,
+		"table_of_contents":      config.TableOfContents,
+		"table_of_contents_next": config.TableOfContentsNext,
+	}
+	for name, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		if err = rule.compile(); err != nil {
+			return nil, fmt.Errorf("%s of source %s: %w", name, config.ID, err)
+		}
+	}
+	if config.TableOfContents != nil && config.TableOfContents.Selector == "" {
+		return nil, fmt.Errorf("table_of_contents of source %s: a selector is required", config.ID)
+	}
+
+	return &declarativeSource{
+		config:    config,
+		novelID:   novelID,
+		chapterID: chapterID,
+	}, nil
+}
+
+func compileIDRegex(expression string) (*regexp.Regexp, error) {
+	if expression == "" {
+		return nil, errors.New("regex cannot be empty")
+	}
+	return regexp.Compile(expression)
+}
+
+func (r *ExtractionRule) compile() error {
+	if r.Selector == "" && r.Regex == "" {
+		return errors.New("a selector or a regex is required")
+	}
+
+	var err error
+	if r.Selector != "" {
+		if r.selector, err = cascadia.Compile(r.Selector); err != nil {
+			return err
+		}
+	}
+	if r.Regex != "" {
+		if r.regex, err = regexp.Compile(r.Regex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extract returns the value the rule finds in the page, or an empty string
+func (r *ExtractionRule) extract(pageContent string) (string, error) {
+	value := pageContent
+	if r.selector != nil {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(pageContent))
+		if err != nil {
+			return "", err
+		}
+
+		selection := doc.FindMatcher(r.selector).First()
+		if selection.Length() == 0 {
+			return "", nil
+		}
+
+		if r.Attribute != "" {
+			value = selection.AttrOr(r.Attribute, "")
+		} else {
+			value = selection.Text()
+		}
+	}
+
+	return strings.TrimSpace(matchRegex(r.regex, value)), nil
+}
+
+// extractHTML returns the HTML of all the elements the rule selects in the page, or an empty string
+func (r *ExtractionRule) extractHTML(pageContent string) (string, error) {
+	if r.selector == nil {
+		return matchRegex(r.regex, pageContent), nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(pageContent))
+	if err != nil {
+		return "", err
+	}
+
+	var content strings.Builder
+	doc.FindMatcher(r.selector).Each(func(_ int, selection *goquery.Selection) {
+		if outerHTML, err := goquery.OuterHtml(selection); err == nil {
+			content.WriteString(matchRegex(r.regex, outerHTML))
+		}
+	})
+	return content.String(), nil
+}
+
+// extractLinks returns the URL and the text of all the elements the rule selects in the page, skipping the URLs its
+// regex does not match
+func (r *ExtractionRule) extractLinks(pageContent, pageUrl string) ([]*models.TableOfContentsEntry, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(pageContent))
+	if err != nil {
+		return nil, err
+	}
+
+	attribute := r.Attribute
+	if attribute == "" {
+		attribute = "href"
+	}
+
+	var entries []*models.TableOfContentsEntry
+	doc.FindMatcher(r.selector).Each(func(_ int, selection *goquery.Selection) {
+		link := selection.AttrOr(attribute, "")
+		if link == "" || (r.regex != nil && !r.regex.MatchString(link)) {
+			return
+		}
+		entries = append(entries, &models.TableOfContentsEntry{
+			URL:           resolveURL(pageUrl, link),
+			OriginalTitle: strings.TrimSpace(selection.Text()),
+		})
+	})
+	return entries, nil
+}
+
+// matchRegex returns the first capture group of the regex in the value, or its whole match when it has no group.
+// A nil regex keeps the value.
+func matchRegex(regex *regexp.Regexp, value string) string {
+	if regex == nil {
+		return value
+	}
+
+	match := regex.FindStringSubmatch(value)
+	switch {
+	case match == nil:
+		return ""
+	case len(match) > 1:
+		return match[1]
+	default:
+		return match[0]
+	}
+}
+
+// resolveURL makes the URL found in a page absolute
+func resolveURL(pageURL, foundURL string) string {
+	if foundURL == "" {
+		return ""
+	}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return foundURL
+	}
+	reference, err := url.Parse(foundURL)
+	if err != nil {
+		return foundURL
+	}
+	return base.ResolveReference(reference).String()
+}
+
+func (s *declarativeSource) GetNovelId(url string) string {
+	return matchRegex(s.novelID, url)
+}
+
+func (s *declarativeSource) GetChapterId(chapterUrl string) string {
+	return matchRegex(s.chapterID, chapterUrl)
+}
+
+// GetNextChapterUrl returns an empty URL when the page has no next chapter link, e.g. for the latest chapter
+func (s *declarativeSource) GetNextChapterUrl(chapterContent, currentChapterUrl string) (string, error) {
+	if s.config.NextChapter == nil {
+		return "", nil
+	}
+
+	nextChapterUrl, err := s.config.NextChapter.extract(chapterContent)
+	if err != nil {
+		return "", err
+	}
+	return resolveURL(currentChapterUrl, nextChapterUrl), nil
+}
+
+func (s *declarativeSource) GetNovelCoverImageUrl(pageContent string) (string, error) {
+	if s.config.Cover == nil {
+		return "", nil
+	}
+
+	coverUrl, err := s.config.Cover.extract(pageContent)
+	if err != nil {
+		return "", err
+	}
+	return resolveURL(s.config.BaseURL, coverUrl), nil
+}
+
+// capabilities depend on the rules the source is configured with
+func (s *declarativeSource) capabilities() []string {
+	capabilities := []string{}
+	if s.config.Content != nil {
+		capabilities = append(capabilities, CapabilityChapterExtraction)
+	}
+	if s.config.TableOfContents != nil {
+		capabilities = append(capabilities, CapabilityTableOfContents)
+	}
+	if s.config.ChapterURL != "" {
+		capabilities = append(capabilities, CapabilityChapterUrlPattern)
+	}
+	return capabilities
+}
+
+func (s *declarativeSource) GetChapterUrlPattern(novelUrl string) string {
+	novelId := s.GetNovelId(novelUrl)
+	if s.config.ChapterURL == "" || novelId == "" {
+		return ""
+	}
+	return strings.ReplaceAll(s.config.ChapterURL, "{novel_id}", novelId)
+}
+
+// GetTableOfContentsUrl returns the novel page, where the table of contents starts
+func (s *declarativeSource) GetTableOfContentsUrl(novelUrl string) string {
+	return novelUrl
+}
+
+func (s *declarativeSource) GetTableOfContents(pageContent, pageUrl string) ([]*models.TableOfContentsEntry, string, error) {
+	if s.config.TableOfContents == nil {
+		return nil, "", ErrNoTableOfContents
+	}
+
+	entries, err := s.config.TableOfContents.extractLinks(pageContent, pageUrl)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if s.config.TableOfContentsNext == nil {
+		return entries, "", nil
+	}
+	nextPageUrl, err := s.config.TableOfContentsNext.extract(pageContent)
+	if err != nil {
+		return nil, "", err
+	}
+	return entries, resolveURL(pageUrl, nextPageUrl), nil
+}
+
+// ExtractChapter keeps the title and the text of the chapter page. ErrChapterNotFound is returned when the source
+// does not configure where they are or they cannot be found.
+func (s *declarativeSource) ExtractChapter(chapterContent string) (string, error) {
+	if s.config.Content == nil {
+		return "", ErrChapterNotFound
+	}
+
+	content, err := s.config.Content.extractHTML(chapterContent)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(content) == "" {
+		return "", ErrChapterNotFound
+	}
+
+	if s.config.Title != nil {
+		title, err := s.config.Title.extract(chapterContent)
+		if err != nil {
+			return "", err
+		}
+		if title != "" {
+			content = "<h1>" + html.EscapeString(title) + "</h1>" + content
+		}
+	}
+
+	return content, nil
+}
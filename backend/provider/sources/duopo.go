@@ -5,11 +5,22 @@ import (
 	"net/url"
 	"strings"
 
+	"backend/models"
+
 	"golang.org/x/net/html"
 )
 
 type duopo struct{}
 
+func init() {
+	Register(&models.SourceSite{
+		ID:       "doupo",
+		Name:     "doupo",
+		URL:      "https://doupo.935666.xyz",
+		Language: "chinese",
+	}, NewDuopo())
+}
+
 func NewDuopo() Source {
 	return &duopo{}
 }
@@ -1,5 +1,17 @@
 package sources
 
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"backend/models"
+)
+
 type Source interface {
 	GetNovelId(url string) string
 	GetChapterId(chapterUrl string) string
@@ -7,29 +19,155 @@ type Source interface {
 	GetNovelCoverImageUrl(pageContent string) (string, error)
 }
 
-func GetSource(sourceType string) Source {
-	switch sourceType {
-	case "69shuba":
-		return NewShuba()
-	case "69yue":
-		return NewYue()
-	case "shuhaige":
-		return NewShuhaige()
-	case "twkan":
-		return NewTwkan()
-	case "doupo":
-		return NewDuopo()
-	case "syosetu":
-		return NewSyosetu()
-	case "ixdzs":
-		return NewIxdzs()
-	case "czbooks":
-		return NewCzbooks()
-	case "quanben":
-		return NewQuanben()
-	case "sjks88":
-		return NewSjks88()
-	default:
-		return nil
+// ChapterExtractor is implemented by the sources able to narrow a chapter page down to the chapter title and text,
+// so that the navigation, comments and advertisements around them are not translated
+type ChapterExtractor interface {
+	ExtractChapter(chapterContent string) (string, error)
+}
+
+// TableOfContentsProvider is implemented by the sources able to list all the chapters of a novel at once, instead of
+// discovering them one at a time from the next chapter links
+type TableOfContentsProvider interface {
+	// GetTableOfContentsUrl returns the URL of the first page listing the chapters of the novel
+	GetTableOfContentsUrl(novelUrl string) string
+
+	// GetTableOfContents returns the chapters listed in a page of the table of contents, in reading order, with the URL
+	// of the next page of the table of contents or an empty URL when it is the last one. Entries are numbered by their
+	// position in the whole table of contents unless the source sets their number.
+	GetTableOfContents(pageContent, pageUrl string) ([]*models.TableOfContentsEntry, string, error)
+}
+
+// ChapterUrlPatternProvider is implemented by the sources whose chapter URLs follow from the chapter number, so that
+// any chapter can be found without walking the next chapter links
+type ChapterUrlPatternProvider interface {
+	// GetChapterUrlPattern returns the URL of the chapters of the novel with ChapterNumberPlaceholder standing for the
+	// chapter number, or an empty pattern when the novel URL is not recognized
+	GetChapterUrlPattern(novelUrl string) string
+}
+
+// ChapterNumberPlaceholder stands for the chapter number in chapter URL patterns
+const ChapterNumberPlaceholder = "{number}"
+
+// ChapterUrl returns the URL of a chapter from the chapter URL pattern of its novel
+func ChapterUrl(pattern string, chapterNumber int) string {
+	return strings.ReplaceAll(pattern, ChapterNumberPlaceholder, strconv.Itoa(chapterNumber))
+}
+
+// Capabilities of a source beyond the Source interface, listed with the source
+const (
+	CapabilityChapterExtraction = "chapter_extraction"
+	CapabilityTableOfContents   = "table_of_contents"
+	CapabilityChapterUrlPattern = "chapter_url_pattern"
+)
+
+// ErrUnknownSource is returned for a source ID no source is registered under
+var ErrUnknownSource = errors.New("unknown source")
+
+// ErrNoTableOfContents is returned for the sources unable to list the chapters of a novel
+var ErrNoTableOfContents = errors.New("source has no table of contents")
+
+// ErrChapterNotFound is returned by the chapter extractors unable to find the chapter in its page
+var ErrChapterNotFound = errors.New("chapter not found in the page")
+
+// registeredSource is a source with the description of its site
+type registeredSource struct {
+	site       *models.SourceSite
+	source     Source
+	configured bool
+}
+
+// registry maps the source IDs to their source
+var registry = map[string]*registeredSource{}
+
+// Register adds a built-in source to the registry under the ID of its site
+func Register(site *models.SourceSite, source Source) {
+	register(site, source, false)
+}
+
+// register adds a source to the registry. Sources configured in the SOURCES_CONFIG file replace the built-in ones
+// with the same ID, whichever registers first.
+func register(site *models.SourceSite, source Source, configured bool) {
+	if site.ID == "" {
+		panic("Source ID cannot be empty")
+	}
+
+	if existing, ok := registry[site.ID]; ok {
+		if existing.configured == configured {
+			panic("Duplicate source registered: " + site.ID)
+		}
+		log.Printf("Source %s configured in SOURCES_CONFIG replaces the built-in one", site.ID)
+		if existing.configured {
+			return
+		}
+	}
+
+	site.Capabilities = capabilities(source)
+	registry[site.ID] = &registeredSource{
+		site:       site,
+		source:     source,
+		configured: configured,
+	}
+}
+
+// configurableSource is implemented by the sources whose capabilities depend on their configuration
+type configurableSource interface {
+	capabilities() []string
+}
+
+// capabilities lists what the source can do beyond the Source interface
+func capabilities(source Source) []string {
+	if configurable, ok := source.(configurableSource); ok {
+		return configurable.capabilities()
+	}
+
+	capabilities := []string{}
+	if _, ok := source.(ChapterExtractor); ok {
+		capabilities = append(capabilities, CapabilityChapterExtraction)
+	}
+	if _, ok := source.(TableOfContentsProvider); ok {
+		capabilities = append(capabilities, CapabilityTableOfContents)
+	}
+	if _, ok := source.(ChapterUrlPatternProvider); ok {
+		capabilities = append(capabilities, CapabilityChapterUrlPattern)
+	}
+	return capabilities
+}
+
+// GetSource returns the source registered under the ID
+func GetSource(id string) (Source, error) {
+	registered, ok := registry[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSource, id)
+	}
+	return registered.source, nil
+}
+
+// GetTableOfContentsProvider returns the source as a TableOfContentsProvider when it has the capability
+func GetTableOfContentsProvider(source Source) (TableOfContentsProvider, error) {
+	provider, ok := source.(TableOfContentsProvider)
+	if !ok || !slices.Contains(capabilities(source), CapabilityTableOfContents) {
+		return nil, ErrNoTableOfContents
+	}
+	return provider, nil
+}
+
+// GetChapterUrlPatternProvider returns the source as a ChapterUrlPatternProvider when it has the capability
+func GetChapterUrlPatternProvider(source Source) (ChapterUrlPatternProvider, bool) {
+	provider, ok := source.(ChapterUrlPatternProvider)
+	if !ok || !slices.Contains(capabilities(source), CapabilityChapterUrlPattern) {
+		return nil, false
+	}
+	return provider, true
+}
+
+// GetSourceSites describes the registered sources, sorted by ID
+func GetSourceSites() []*models.SourceSite {
+	sites := make([]*models.SourceSite, 0, len(registry))
+	for _, registered := range registry {
+		sites = append(sites, registered.site)
 	}
+	sort.Slice(sites, func(i, j int) bool {
+		return sites[i].ID < sites[j].ID
+	})
+	return sites
 }
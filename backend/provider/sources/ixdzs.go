@@ -3,10 +3,22 @@ package sources
 import (
 	"strconv"
 	"strings"
+
+	"backend/models"
 )
 
 type ixdzs struct{}
 
+func init() {
+	Register(&models.SourceSite{
+		ID:       "ixdzs",
+		Name:     "ixdzs",
+		URL:      "https://ixdzs.tw",
+		Mirrors:  []string{"https://ixdzs.com"},
+		Language: "chinese",
+	}, NewIxdzs())
+}
+
 func NewIxdzs() Source {
 	return &ixdzs{}
 }
@@ -63,6 +75,16 @@ func (s ixdzs) GetNextChapterUrl(chapterContent, currentChapterUrl string) (stri
 	return nextChapterUrl, nil
 }
 
+// GetChapterUrlPattern returns the chapter URLs, which are numbered from 1 under the novel URL
+func (s ixdzs) GetChapterUrlPattern(novelUrl string) string {
+	// Example URL: https://ixdzs.tw/read/526058/ -> https://ixdzs.tw/read/526058/p{number}.html
+	parts := strings.Split(novelUrl, "/")
+	if len(parts) < 5 || parts[4] == "" {
+		return ""
+	}
+	return strings.Join(parts[:5], "/") + "/p" + ChapterNumberPlaceholder + ".html"
+}
+
 func (s ixdzs) GetNovelCoverImageUrl(pageContent string) (string, error) {
 	// The url is present in <meta property="og:image" content="https://img22.ixdzs.com/e9/5c/e95c75a9eb2af8efa3e9cc00471700d8.jpg" />
 	startIdx := strings.Index(pageContent, `<meta property="og:image" content="`)
@@ -0,0 +1,26 @@
+package sources
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// pageChrome selects the parts of a page that are never part of a chapter
+const pageChrome = "script, style, noscript, nav, footer, aside, form, iframe"
+
+// StripPageChrome removes the scripts, navigation, footers, sidebars and forms from a page, for the chapter pages
+// their source cannot narrow down to the chapter. The page is kept as is when it cannot be parsed.
+func StripPageChrome(pageContent string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(pageContent))
+	if err != nil {
+		return pageContent
+	}
+
+	doc.Find(pageChrome).Remove()
+	content, err := doc.Find("body").Html()
+	if err != nil {
+		return pageContent
+	}
+	return content
+}
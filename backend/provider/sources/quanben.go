@@ -1,9 +1,23 @@
 package sources
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+
+	"backend/models"
+)
 
 type quanben struct{}
 
+func init() {
+	Register(&models.SourceSite{
+		ID:       "quanben",
+		Name:     "quanben",
+		URL:      "https://www.quanben.io",
+		Language: "chinese",
+	}, NewQuanben())
+}
+
 func NewQuanben() *quanben {
 	return &quanben{}
 }
@@ -32,6 +46,21 @@ func (q *quanben) GetChapterId(chapterUrl string) string {
 	return novelId + "_" + chapterFile[0]
 }
 
+// IsChapterUrl tells the chapter pages from the chapter list, which is a page of the novel directory too
+func (q *quanben) IsChapterUrl(url string) bool {
+	// Example URL: https://www.quanben.io/n/gaowu-wodetianfuwuxianshengji/1.html
+	parts := strings.Split(url, "/")
+	if len(parts) < 6 {
+		return false
+	}
+	chapterFile := strings.Split(parts[5], ".")
+	if len(chapterFile) < 2 {
+		return false
+	}
+	_, err := strconv.Atoi(chapterFile[0])
+	return err == nil
+}
+
 func (q *quanben) GetNextChapterUrl(chapterContent, currentChapterUrl string) (string, error) {
 	// Next chapter link: <a href="/n/gaowu-wodetianfuwuxianshengji/2.html" itemprop="url" rel="next">下一页</a>
 	relNext := `rel="next"`
@@ -0,0 +1,92 @@
+package sources
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"backend/models"
+)
+
+// ChapterPageMatcher is implemented by the sources whose novel URLs cannot be told apart from their chapter URLs
+// by the IDs found in them
+type ChapterPageMatcher interface {
+	IsChapterUrl(url string) bool
+}
+
+// ResolveSource finds the registered source serving the URL, from its host and path matched against the base URL
+// and the mirrors of every source. The longest matching base path wins when several sources share a host.
+func ResolveSource(pageUrl string) (*models.SourceResolution, error) {
+	page, err := parseSourceURL(pageUrl)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid URL %s", ErrUnknownSource, pageUrl)
+	}
+
+	var match *registeredSource
+	matchLength := -1
+	for _, registered := range registry {
+		for _, baseUrl := range append([]string{registered.site.URL}, registered.site.Mirrors...) {
+			base, err := parseSourceURL(baseUrl)
+			if err != nil || normalizeHost(base.Host) != normalizeHost(page.Host) {
+				continue
+			}
+
+			basePath := strings.TrimSuffix(base.Path, "/")
+			if !strings.HasPrefix(page.Path+"/", basePath+"/") {
+				continue
+			}
+
+			// Ties are broken by ID so that the same URL always resolves to the same source
+			if len(basePath) > matchLength || (len(basePath) == matchLength && registered.site.ID < match.site.ID) {
+				match, matchLength = registered, len(basePath)
+			}
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: no source matches %s", ErrUnknownSource, pageUrl)
+	}
+
+	resolution := &models.SourceResolution{
+		Source:   match.site.ID,
+		NovelID:  match.source.GetNovelId(pageUrl),
+		PageType: models.PageTypeNovel,
+	}
+	if isChapterUrl(match.source, pageUrl, resolution.NovelID) {
+		resolution.PageType = models.PageTypeChapter
+		resolution.ChapterID = match.source.GetChapterId(pageUrl)
+
+		// Sources reading both IDs from the name of the page find the chapter ID again instead of the novel ID
+		if resolution.NovelID == resolution.ChapterID {
+			resolution.NovelID = ""
+		}
+	}
+	return resolution, nil
+}
+
+// isChapterUrl reports whether the URL is a chapter page. Unless the source can tell, it is one when a chapter ID
+// other than the novel ID is found in it.
+func isChapterUrl(source Source, pageUrl, novelID string) bool {
+	if matcher, ok := source.(ChapterPageMatcher); ok {
+		return matcher.IsChapterUrl(pageUrl)
+	}
+
+	chapterID := source.GetChapterId(pageUrl)
+	return chapterID != "" && chapterID != novelID
+}
+
+// parseSourceURL parses an absolute URL
+func parseSourceURL(rawUrl string) (*url.URL, error) {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("URL %s has no host", rawUrl)
+	}
+	return parsed, nil
+}
+
+// normalizeHost compares hosts without their case and www. prefix
+func normalizeHost(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
@@ -4,11 +4,22 @@ import (
 	"fmt"
 	"strings"
 
+	"backend/models"
+
 	"golang.org/x/net/html"
 )
 
 type shuba struct{}
 
+func init() {
+	Register(&models.SourceSite{
+		ID:       "69shuba",
+		Name:     "69shuba",
+		URL:      "https://www.69shuba.com",
+		Language: "chinese",
+	}, NewShuba())
+}
+
 // NewShuba creates a new instance of the Shuba source
 func NewShuba() Source {
 	return &shuba{}
@@ -1,9 +1,22 @@
 package sources
 
-import "strings"
+import (
+	"strings"
+
+	"backend/models"
+)
 
 type shuhaige struct{}
 
+func init() {
+	Register(&models.SourceSite{
+		ID:       "shuhaige",
+		Name:     "shuhaige",
+		URL:      "https://m.shuhaige.net",
+		Language: "chinese",
+	}, NewShuhaige())
+}
+
 func NewShuhaige() Source {
 	return &shuhaige{}
 }
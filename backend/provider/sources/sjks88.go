@@ -3,10 +3,22 @@ package sources
 import (
 	"strconv"
 	"strings"
+
+	"backend/models"
 )
 
 type sjks88 struct{}
 
+func init() {
+	Register(&models.SourceSite{
+		ID:       "sjks88",
+		Name:     "sjks88",
+		URL:      "https://www.sjks88.com",
+		Mirrors:  []string{"https://sjks88.com"},
+		Language: "chinese",
+	}, NewSjks88())
+}
+
 func NewSjks88() *sjks88 {
 	return &sjks88{}
 }
@@ -44,6 +56,15 @@ func (s *sjks88) GetNextChapterUrl(chapterContent, currentChapterUrl string) (st
 	return strings.Join(parts[:5], "/") + "/" + strconv.Itoa(currentNum+1) + ".html", nil
 }
 
+// GetChapterUrlPattern returns the chapter URLs, which are numbered from 1 under the novel URL
+func (s *sjks88) GetChapterUrlPattern(novelUrl string) string {
+	// Example URL: https://www.sjks88.com/xuanhuan/51964.html -> https://www.sjks88.com/xuanhuan/51964/{number}.html
+	if s.GetNovelId(novelUrl) == "" || !strings.HasSuffix(novelUrl, ".html") {
+		return ""
+	}
+	return strings.TrimSuffix(novelUrl, ".html") + "/" + ChapterNumberPlaceholder + ".html"
+}
+
 func (s *sjks88) GetNovelCoverImageUrl(pageContent string) (string, error) {
 	// Cover image is in <meta property="og:image" content="...">
 	marker := `property="og:image"`
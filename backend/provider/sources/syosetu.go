@@ -2,12 +2,27 @@ package sources
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
+
+	"backend/models"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
 type syosetu struct{}
 
+func init() {
+	Register(&models.SourceSite{
+		ID:       "syosetu",
+		Name:     "syosetu",
+		URL:      "https://syosetu.com/",
+		Mirrors:  []string{"https://ncode.syosetu.com"},
+		Language: "japanese",
+	}, NewSyosetu())
+}
+
 // NewSyosetu creates a new instance of the Syosetu source
 func NewSyosetu() Source {
 	return &syosetu{}
@@ -61,3 +76,49 @@ func (s syosetu) GetNextChapterUrl(chapterContent, currentChapterUrl string) (st
 func (s syosetu) GetNovelCoverImageUrl(pageContent string) (string, error) {
 	return "", nil
 }
+
+// GetChapterUrlPattern returns the chapter URLs, which are numbered from 1, e.g. https://ncode.syosetu.com/n1514kj/1/
+func (s syosetu) GetChapterUrlPattern(novelUrl string) string {
+	novelId := s.GetNovelId(novelUrl)
+	if novelId == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://ncode.syosetu.com/%s/%s/", novelId, ChapterNumberPlaceholder)
+}
+
+// syosetuChapterUrl matches the chapter links of the table of contents, e.g. https://ncode.syosetu.com/n1514kj/1/,
+// capturing the chapter number
+var syosetuChapterUrl = regexp.MustCompile(`^https://ncode\.syosetu\.com/[^/]+/(\d+)/$`)
+
+// GetTableOfContentsUrl returns the novel page, which lists the chapters 100 at a time
+func (s syosetu) GetTableOfContentsUrl(novelUrl string) string {
+	novelId := s.GetNovelId(novelUrl)
+	if novelId == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://ncode.syosetu.com/%s/", novelId)
+}
+
+func (s syosetu) GetTableOfContents(pageContent, pageUrl string) ([]*models.TableOfContentsEntry, string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(pageContent))
+	if err != nil {
+		return nil, "", err
+	}
+
+	var entries []*models.TableOfContentsEntry
+	doc.Find("a[href]").Each(func(_ int, link *goquery.Selection) {
+		chapterUrl := resolveURL(pageUrl, link.AttrOr("href", ""))
+		if match := syosetuChapterUrl.FindStringSubmatch(chapterUrl); match != nil {
+			number, _ := strconv.Atoi(match[1])
+			entries = append(entries, &models.TableOfContentsEntry{
+				Number:        number,
+				URL:           chapterUrl,
+				OriginalTitle: strings.TrimSpace(link.Text()),
+			})
+		}
+	})
+
+	// The following pages are linked from the pager, e.g. https://ncode.syosetu.com/n1514kj/?p=2
+	nextPageUrl := resolveURL(pageUrl, doc.Find("a.c-pager__item--next").First().AttrOr("href", ""))
+	return entries, nextPageUrl, nil
+}
@@ -4,11 +4,22 @@ import (
 	"fmt"
 	"strings"
 
+	"backend/models"
+
 	"golang.org/x/net/html"
 )
 
 type twkan struct{}
 
+func init() {
+	Register(&models.SourceSite{
+		ID:       "twkan",
+		Name:     "twkan",
+		URL:      "https://twkan.com",
+		Language: "chinese",
+	}, NewTwkan())
+}
+
 // NewTwkan creates a new instance of the Shuba source
 func NewTwkan() Source {
 	return &twkan{}
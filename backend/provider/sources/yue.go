@@ -1,9 +1,22 @@
 package sources
 
-import "strings"
+import (
+	"strings"
+
+	"backend/models"
+)
 
 type yue struct{}
 
+func init() {
+	Register(&models.SourceSite{
+		ID:       "69yue",
+		Name:     "69yue",
+		URL:      "https://www.69yue.top",
+		Language: "chinese",
+	}, NewYue())
+}
+
 func NewYue() Source {
 	return &yue{}
 }
@@ -38,6 +51,12 @@ func (y yue) GetChapterId(chapterUrl string) string {
 	return lastParts[0] // Return the part before the ".html"
 }
 
+// IsChapterUrl tells the chapter pages from the novel pages, whose IDs are both the name of the page
+func (y yue) IsChapterUrl(url string) bool {
+	// Example URL: https://www.69yue.top/article/15189329271721020.html
+	return strings.Contains(url, "/article/")
+}
+
 func (y yue) GetNextChapterUrl(chapterContent, currentChapterUrl string) (string, error) {
 	startIdx := strings.Index(chapterContent, `下一章`)
 	if startIdx == -1 {
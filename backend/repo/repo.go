@@ -3,7 +3,6 @@ package repo
 import (
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
@@ -35,32 +34,109 @@ type Repo interface {
 	UpdateLastReadChapter(novelID string, chapterNumber int) error
 
 	// Chapter methods
-	GetNovelChapters(novelID string) ([]*models.Chapter, error)
-	GetLastChapter(novelID string) (*models.Chapter, error)
+	GetNovelChapters(novelID string, language string) ([]*models.Chapter, error)
+	GetLastChapter(novelID string, language string) (*models.Chapter, error)
 	GetChapterByID(novelID string, chapterID string) (*models.Chapter, error)
-	GetChapterByNumber(novelID string, chapterNumber int) (*models.Chapter, error)
-	GetChapterByURL(url string) (*models.Chapter, error)
+	GetChapterByNumber(novelID string, chapterNumber int, language string) (*models.Chapter, error)
+	GetChapterByURL(url string, language string) (*models.Chapter, error)
 	CreateChapter(chapter *models.Chapter) (*models.Chapter, error)
 	UpdateChapter(chapter *models.Chapter) error
 	DeleteChapter(novelID string, chapterID string) error
+
+	// Chapter version methods
+	GetChapterVersions(novelID string, chapterID string) ([]*models.ChapterVersion, error)
+	GetChapterVersion(novelID string, chapterID string, version int) (*models.ChapterVersion, error)
+	GetLatestChapterVersionNumber(novelID string, chapterID string) (int, error)
+	CreateChapterVersion(version *models.ChapterVersion) (*models.ChapterVersion, error)
+
+	// Chapter source methods
+	GetChapterSource(novelID string, chapterID string) (*models.ChapterSource, error)
+	GetChapterSources(novelID string, chapterIDs []string) ([]*models.ChapterSource, error)
+	SaveChapterSource(source *models.ChapterSource) error
+
+	// Chapter alignment methods
+	GetChapterAlignment(novelID string, chapterID string, version int) (*models.ChapterAlignment, error)
+	SaveChapterAlignment(alignment *models.ChapterAlignment) error
+
+	// Table of contents methods
+	GetTableOfContents(novelID string) ([]*models.TableOfContentsEntry, error)
+	SaveTableOfContents(novelID string, entries []*models.TableOfContentsEntry) error
+
+	// Style preset methods
+	GetStylePresets() ([]*models.StylePreset, error)
+	GetStylePreset(name string) (*models.StylePreset, error)
+	CreateStylePreset(preset *models.StylePreset) (*models.StylePreset, error)
+	UpdateStylePreset(preset *models.StylePreset) error
+	DeleteStylePreset(name string) error
+
+	// Glossary methods
+	GetGlossaryTerms(novelID string) ([]*models.GlossaryTerm, error)
+	GetGlossaryTermByID(novelID string, termID string) (*models.GlossaryTerm, error)
+	GetGlossaryTermByOriginal(novelID string, original string) (*models.GlossaryTerm, error)
+	CreateGlossaryTerm(term *models.GlossaryTerm) (*models.GlossaryTerm, error)
+	UpdateGlossaryTerm(term *models.GlossaryTerm) error
+	DeleteGlossaryTerm(novelID string, termID string) error
+
+	// Story context methods
+	GetStoryContext(novelID string) (*models.StoryContext, error)
+	SaveStoryContext(storyContext *models.StoryContext) error
+
+	// Usage methods
+	CreateLLMUsage(usage *models.LLMUsage) error
+	GetUsageSummaries(groupBy string, since int64) ([]*models.UsageSummary, error)
+	GetNovelUsageSummary(novelID string) (*models.UsageSummary, error)
+
+	// LLM cache methods
+	GetLLMCacheEntry(key string) (*models.LLMCacheEntry, error)
+	SaveLLMCacheEntry(entry *models.LLMCacheEntry) error
+}
+
+// chapterColumns lists the chapter columns in the order expected by models.ScanChapter
+const chapterColumns = `id, novel_id, number, title, original_title, content, date_translated, word_count, url, next_chapter_url, provider, attempts, quality_score, quality_flags, language, version, style, prompt_version`
+
+// chapterListColumns lists the chapter columns in the order expected by models.ScanChapters
+const chapterListColumns = `id, novel_id, number, title, original_title, date_translated, word_count, url, next_chapter_url, provider, attempts, quality_score, quality_flags, language, version, style, prompt_version`
+
+// chapterVersionColumns lists the chapter version columns in the order expected by models.ScanChapterVersion
+const chapterVersionColumns = `id, chapter_id, novel_id, version, title, original_title, content, word_count, provider, model, style, prompt_version, attempts, quality_score, quality_flags, date_translated`
+
+// chapterVersionListColumns lists the chapter version columns in the order expected by models.ScanChapterVersions
+const chapterVersionListColumns = `id, chapter_id, novel_id, version, title, original_title, word_count, provider, model, style, prompt_version, attempts, quality_score, quality_flags, date_translated`
+
+// glossaryTermColumns lists the glossary term columns in the order expected by models.ScanGlossaryTerm
+const glossaryTermColumns = `id, novel_id, original, translated, category, notes, auto_extracted, conflicting_translation, conflict_chapter_number, date_added, last_updated`
+
+// usageGroupings maps the supported usage breakdowns to their key and name expressions.
+// The empty grouping aggregates all usage into a single summary.
+var usageGroupings = map[string]struct {
+	key  string
+	name string
+}{
+	"":         {key: "''", name: "''"},
+	"day":      {key: "strftime('%Y-%m-%d', u.timestamp, 'unixepoch')", name: "''"},
+	"novel":    {key: "u.novel_id", name: "COALESCE(MAX(n.title), '')"},
+	"provider": {key: "u.provider", name: "''"},
 }
 
 type repo struct {
 	db DB
 }
 
-func init() {
+// OpenDatabase opens the SQLite database of the application and sets up the repository returned by GetRepo.
+// It is called once at startup, before the services are initialized.
+func OpenDatabase() error {
 	// Set up the database file path
 	dbPath := utils.GetDBPath()
 
 	// Initialize SQLite database
 	db, err := NewSQLiteDB(dbPath)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		return err
 	}
 
 	// Initialize repository
 	repository = NewRepo(db)
+	return nil
 }
 
 func NewRepo(db DB) Repo {
@@ -272,8 +348,8 @@ func (r *repo) CreateNovel(novel *models.Novel) (*models.Novel, error) {
 	}
 
 	query := `
-		INSERT INTO novels (id, title, original_title, cover, source, url, summary, author, status, genres, chapters_count, last_read_chapter_number, last_read_timestamp, last_updated, date_added)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO novels (id, title, original_title, cover, source, url, summary, author, status, genres, chapters_count, last_read_chapter_number, last_read_timestamp, last_updated, date_added, target_language, style)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err = r.db.Exec(
@@ -293,6 +369,8 @@ func (r *repo) CreateNovel(novel *models.Novel) (*models.Novel, error) {
 		novel.LastReadTimestamp,
 		novel.LastUpdated,
 		novel.DateAdded,
+		novel.TargetLanguage,
+		novel.Style,
 	)
 	if err != nil {
 		return nil, err
@@ -312,7 +390,7 @@ func (r *repo) UpdateNovel(novel *models.Novel) error {
 		UPDATE novels
 		SET title = ?, original_title = ?, cover = ?, source = ?, url = ?, 
 		    summary = ?, author = ?, status = ?, genres = ?, chapters_count = ?, 
-		    last_read_chapter_number = ?, last_read_timestamp = ?, last_updated = ?
+		    last_read_chapter_number = ?, last_read_timestamp = ?, last_updated = ?, target_language = ?, style = ?
 		WHERE id = ?
 	`
 
@@ -331,6 +409,8 @@ func (r *repo) UpdateNovel(novel *models.Novel) error {
 		novel.LastReadChapterNumber,
 		novel.LastReadTimestamp,
 		novel.LastUpdated,
+		novel.TargetLanguage,
+		novel.Style,
 		novel.ID,
 	)
 	if err != nil {
@@ -356,6 +436,40 @@ func (r *repo) DeleteNovel(id string) error {
 		return err
 	}
 
+	// Delete the versions, sources and alignments of its chapters
+	_, err = r.db.Exec("DELETE FROM chapter_versions WHERE novel_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec("DELETE FROM chapter_sources WHERE novel_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec("DELETE FROM chapter_alignments WHERE novel_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	// Delete the table of contents of this novel
+	_, err = r.db.Exec("DELETE FROM table_of_contents WHERE novel_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	// Delete the glossary of this novel
+	_, err = r.db.Exec("DELETE FROM glossary_terms WHERE novel_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	// Delete the story context of this novel
+	_, err = r.db.Exec("DELETE FROM story_contexts WHERE novel_id = ?", id)
+	if err != nil {
+		return err
+	}
+
 	// Then delete the novel
 	result, err := r.db.Exec("DELETE FROM novels WHERE id = ?", id)
 	if err != nil {
@@ -390,16 +504,16 @@ func (r *repo) UpdateLastReadChapter(novelID string, chapterNumber int) error {
 
 // Chapter CRUD Operations
 
-func (r *repo) GetNovelChapters(novelID string) ([]*models.Chapter, error) {
+func (r *repo) GetNovelChapters(novelID string, language string) ([]*models.Chapter, error) {
 	// We are not loading the content of the chapters to decrease the memory usage
 	query := `
-		SELECT id, novel_id, number, title, original_title, date_translated, word_count, url, next_chapter_url
+		SELECT ` + chapterListColumns + `
 		FROM chapters
-		WHERE novel_id = ?
+		WHERE novel_id = ? AND language = ?
 		ORDER BY number
 	`
 
-	rows, err := r.db.Query(query, novelID)
+	rows, err := r.db.Query(query, novelID, language)
 	if err != nil {
 		return nil, err
 	}
@@ -408,22 +522,22 @@ func (r *repo) GetNovelChapters(novelID string) ([]*models.Chapter, error) {
 	return models.ScanChapters(rows)
 }
 
-func (r *repo) GetLastChapter(novelID string) (*models.Chapter, error) {
+func (r *repo) GetLastChapter(novelID string, language string) (*models.Chapter, error) {
 	query := `
-		SELECT *
+		SELECT ` + chapterColumns + `
 		FROM chapters
-		WHERE novel_id = ?
+		WHERE novel_id = ? AND language = ?
 		ORDER BY number DESC
 		LIMIT 1
 	`
 
-	row := r.db.QueryRow(query, novelID)
+	row := r.db.QueryRow(query, novelID, language)
 	return models.ScanChapter(row)
 }
 
 func (r *repo) GetChapterByID(novelID string, chapterID string) (*models.Chapter, error) {
 	query := `
-		SELECT *
+		SELECT ` + chapterColumns + `
 		FROM chapters
 		WHERE novel_id = ? AND id = ?
 	`
@@ -432,25 +546,25 @@ func (r *repo) GetChapterByID(novelID string, chapterID string) (*models.Chapter
 	return models.ScanChapter(row)
 }
 
-func (r *repo) GetChapterByNumber(novelID string, chapterNumber int) (*models.Chapter, error) {
+func (r *repo) GetChapterByNumber(novelID string, chapterNumber int, language string) (*models.Chapter, error) {
 	query := `
-		SELECT *
+		SELECT ` + chapterColumns + `
 		FROM chapters
-		WHERE novel_id = ? AND number = ?
+		WHERE novel_id = ? AND number = ? AND language = ?
 	`
 
-	row := r.db.QueryRow(query, novelID, chapterNumber)
+	row := r.db.QueryRow(query, novelID, chapterNumber, language)
 	return models.ScanChapter(row)
 }
 
-func (r *repo) GetChapterByURL(url string) (*models.Chapter, error) {
+func (r *repo) GetChapterByURL(url string, language string) (*models.Chapter, error) {
 	query := `
-		SELECT *
+		SELECT ` + chapterColumns + `
 		FROM chapters
-		WHERE url = ?
+		WHERE url = ? AND language = ?
 	`
 
-	row := r.db.QueryRow(query, url)
+	row := r.db.QueryRow(query, url, language)
 	return models.ScanChapter(row)
 }
 
@@ -460,13 +574,19 @@ func (r *repo) CreateChapter(chapter *models.Chapter) (*models.Chapter, error) {
 		chapter.ID = uuid.New().String()
 	}
 
+	qualityFlagsJSON, err := models.QualityFlagsToJSON(chapter.QualityFlags)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		INSERT INTO chapters (
-			id, novel_id, number, title, original_title, content, date_translated, word_count, url, next_chapter_url
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			id, novel_id, number, title, original_title, content, date_translated, word_count, url, next_chapter_url, provider, attempts,
+			quality_score, quality_flags, language, version, style, prompt_version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := r.db.Exec(
+	_, err = r.db.Exec(
 		query,
 		chapter.ID,
 		chapter.NovelID,
@@ -478,19 +598,34 @@ func (r *repo) CreateChapter(chapter *models.Chapter) (*models.Chapter, error) {
 		chapter.WordCount,
 		chapter.URL,
 		chapter.NextChapterURL,
+		chapter.Provider,
+		chapter.Attempts,
+		chapter.QualityScore,
+		qualityFlagsJSON,
+		chapter.Language,
+		chapter.Version,
+		chapter.Style,
+		chapter.PromptVersion,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	chapter.Translated = true
 	return chapter, nil
 }
 
 func (r *repo) UpdateChapter(chapter *models.Chapter) error {
+	qualityFlagsJSON, err := models.QualityFlagsToJSON(chapter.QualityFlags)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE chapters
 		SET number = ?, title = ?, original_title = ?, content = ?, 
-		    date_translated = ?, word_count = ?, url = ?, next_chapter_url = ?
+		    date_translated = ?, word_count = ?, url = ?, next_chapter_url = ?, provider = ?, attempts = ?,
+		    quality_score = ?, quality_flags = ?, language = ?, version = ?, style = ?, prompt_version = ?
 		WHERE id = ? AND novel_id = ?
 	`
 
@@ -504,6 +639,14 @@ func (r *repo) UpdateChapter(chapter *models.Chapter) error {
 		chapter.WordCount,
 		chapter.URL,
 		chapter.NextChapterURL,
+		chapter.Provider,
+		chapter.Attempts,
+		chapter.QualityScore,
+		qualityFlagsJSON,
+		chapter.Language,
+		chapter.Version,
+		chapter.Style,
+		chapter.PromptVersion,
 		chapter.ID,
 		chapter.NovelID,
 	)
@@ -524,6 +667,22 @@ func (r *repo) UpdateChapter(chapter *models.Chapter) error {
 }
 
 func (r *repo) DeleteChapter(novelID string, chapterID string) error {
+	// Delete the versions, the source and the alignments of the chapter first
+	_, err := r.db.Exec("DELETE FROM chapter_versions WHERE novel_id = ? AND chapter_id = ?", novelID, chapterID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec("DELETE FROM chapter_sources WHERE novel_id = ? AND chapter_id = ?", novelID, chapterID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec("DELETE FROM chapter_alignments WHERE novel_id = ? AND chapter_id = ?", novelID, chapterID)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		DELETE FROM chapters
 		WHERE novel_id = ? AND id = ?
@@ -545,3 +704,623 @@ func (r *repo) DeleteChapter(novelID string, chapterID string) error {
 
 	return nil
 }
+
+// Chapter version operations
+
+func (r *repo) GetChapterVersions(novelID string, chapterID string) ([]*models.ChapterVersion, error) {
+	// We are not loading the content of the versions to decrease the memory usage
+	query := `
+		SELECT ` + chapterVersionListColumns + `
+		FROM chapter_versions
+		WHERE novel_id = ? AND chapter_id = ?
+		ORDER BY version
+	`
+
+	rows, err := r.db.Query(query, novelID, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return models.ScanChapterVersions(rows)
+}
+
+func (r *repo) GetChapterVersion(novelID string, chapterID string, version int) (*models.ChapterVersion, error) {
+	query := `
+		SELECT ` + chapterVersionColumns + `
+		FROM chapter_versions
+		WHERE novel_id = ? AND chapter_id = ? AND version = ?
+	`
+
+	row := r.db.QueryRow(query, novelID, chapterID, version)
+	return models.ScanChapterVersion(row)
+}
+
+// GetLatestChapterVersionNumber returns the highest version number of the chapter, 0 when it has no versions
+func (r *repo) GetLatestChapterVersionNumber(novelID string, chapterID string) (int, error) {
+	var version int
+	err := r.db.QueryRow(
+		"SELECT COALESCE(MAX(version), 0) FROM chapter_versions WHERE novel_id = ? AND chapter_id = ?",
+		novelID, chapterID,
+	).Scan(&version)
+	return version, err
+}
+
+func (r *repo) CreateChapterVersion(version *models.ChapterVersion) (*models.ChapterVersion, error) {
+	// Generate a new UUID if not provided
+	if version.ID == "" {
+		version.ID = uuid.New().String()
+	}
+
+	qualityFlagsJSON, err := models.QualityFlagsToJSON(version.QualityFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO chapter_versions (` + chapterVersionColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = r.db.Exec(
+		query,
+		version.ID,
+		version.ChapterID,
+		version.NovelID,
+		version.Version,
+		version.Title,
+		version.OriginalTitle,
+		version.Content,
+		version.WordCount,
+		version.Provider,
+		version.Model,
+		version.Style,
+		version.PromptVersion,
+		version.Attempts,
+		version.QualityScore,
+		qualityFlagsJSON,
+		version.DateTranslated,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+// Chapter source operations
+
+func (r *repo) GetChapterSource(novelID string, chapterID string) (*models.ChapterSource, error) {
+	query := `
+		SELECT chapter_id, novel_id, original_text, source_hash, extracted, date_scraped
+		FROM chapter_sources
+		WHERE novel_id = ? AND chapter_id = ?
+	`
+
+	row := r.db.QueryRow(query, novelID, chapterID)
+	return models.ScanChapterSource(row)
+}
+
+// GetChapterSources returns the kept originals of the chapters, skipping the chapters that have none
+func (r *repo) GetChapterSources(novelID string, chapterIDs []string) ([]*models.ChapterSource, error) {
+	if len(chapterIDs) == 0 {
+		return []*models.ChapterSource{}, nil
+	}
+
+	// Create placeholders for each chapter
+	placeholders := make([]string, len(chapterIDs))
+	args := make([]interface{}, 0, len(chapterIDs)+1)
+	args = append(args, novelID)
+	for i, chapterID := range chapterIDs {
+		placeholders[i] = "?"
+		args = append(args, chapterID)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT chapter_id, novel_id, original_text, source_hash, extracted, date_scraped
+		FROM chapter_sources
+		WHERE novel_id = ? AND chapter_id IN (%s)
+	`, strings.Join(placeholders, ","))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return models.ScanChapterSources(rows)
+}
+
+func (r *repo) SaveChapterSource(source *models.ChapterSource) error {
+	query := `
+		INSERT INTO chapter_sources (chapter_id, novel_id, original_text, source_hash, extracted, date_scraped)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(chapter_id) DO UPDATE SET
+			original_text = excluded.original_text,
+			source_hash = excluded.source_hash,
+			extracted = excluded.extracted,
+			date_scraped = excluded.date_scraped
+	`
+
+	_, err := r.db.Exec(
+		query,
+		source.ChapterID,
+		source.NovelID,
+		source.OriginalText,
+		source.SourceHash,
+		source.Extracted,
+		source.DateScraped,
+	)
+	return err
+}
+
+// Table of contents operations
+
+func (r *repo) GetTableOfContents(novelID string) ([]*models.TableOfContentsEntry, error) {
+	query := `
+		SELECT novel_id, number, url, original_title, date_scraped
+		FROM table_of_contents
+		WHERE novel_id = ?
+		ORDER BY number
+	`
+
+	rows, err := r.db.Query(query, novelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return models.ScanTableOfContents(rows)
+}
+
+// SaveTableOfContents replaces the table of contents of the novel, keeping the previous one when it fails
+func (r *repo) SaveTableOfContents(novelID string, entries []*models.TableOfContentsEntry) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM table_of_contents WHERE novel_id = ?", novelID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO table_of_contents (novel_id, number, url, original_title, date_scraped)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	for _, entry := range entries {
+		_, err = tx.Exec(
+			query,
+			novelID,
+			entry.Number,
+			entry.URL,
+			entry.OriginalTitle,
+			entry.DateScraped,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Chapter alignment operations
+
+func (r *repo) GetChapterAlignment(novelID string, chapterID string, version int) (*models.ChapterAlignment, error) {
+	query := `
+		SELECT chapter_id, novel_id, version, paragraphs, date_aligned
+		FROM chapter_alignments
+		WHERE novel_id = ? AND chapter_id = ? AND version = ?
+	`
+
+	row := r.db.QueryRow(query, novelID, chapterID, version)
+	return models.ScanChapterAlignment(row)
+}
+
+func (r *repo) SaveChapterAlignment(alignment *models.ChapterAlignment) error {
+	paragraphsJSON, err := models.AlignedParagraphsToJSON(alignment.Paragraphs)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO chapter_alignments (chapter_id, novel_id, version, paragraphs, date_aligned)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(chapter_id, version) DO UPDATE SET
+			paragraphs = excluded.paragraphs,
+			date_aligned = excluded.date_aligned
+	`
+
+	_, err = r.db.Exec(
+		query,
+		alignment.ChapterID,
+		alignment.NovelID,
+		alignment.Version,
+		paragraphsJSON,
+		alignment.DateAligned,
+	)
+	return err
+}
+
+// Style preset CRUD Operations
+
+func (r *repo) GetStylePresets() ([]*models.StylePreset, error) {
+	query := `
+		SELECT name, description, instructions, date_added, last_updated
+		FROM style_presets
+		ORDER BY name
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return models.ScanStylePresets(rows)
+}
+
+func (r *repo) GetStylePreset(name string) (*models.StylePreset, error) {
+	query := `
+		SELECT name, description, instructions, date_added, last_updated
+		FROM style_presets
+		WHERE name = ?
+	`
+
+	row := r.db.QueryRow(query, name)
+	return models.ScanStylePreset(row)
+}
+
+func (r *repo) CreateStylePreset(preset *models.StylePreset) (*models.StylePreset, error) {
+	query := `
+		INSERT INTO style_presets (name, description, instructions, date_added, last_updated)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query, preset.Name, preset.Description, preset.Instructions, preset.DateAdded, preset.LastUpdated)
+	if err != nil {
+		return nil, err
+	}
+
+	return preset, nil
+}
+
+func (r *repo) UpdateStylePreset(preset *models.StylePreset) error {
+	query := `
+		UPDATE style_presets
+		SET description = ?, instructions = ?, last_updated = ?
+		WHERE name = ?
+	`
+
+	result, err := r.db.Exec(query, preset.Description, preset.Instructions, preset.LastUpdated, preset.Name)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("style preset not found")
+	}
+
+	return nil
+}
+
+func (r *repo) DeleteStylePreset(name string) error {
+	result, err := r.db.Exec("DELETE FROM style_presets WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("style preset not found")
+	}
+
+	return nil
+}
+
+// Glossary CRUD Operations
+
+func (r *repo) GetGlossaryTerms(novelID string) ([]*models.GlossaryTerm, error) {
+	query := `
+		SELECT ` + glossaryTermColumns + `
+		FROM glossary_terms
+		WHERE novel_id = ?
+		ORDER BY original
+	`
+
+	rows, err := r.db.Query(query, novelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return models.ScanGlossaryTerms(rows)
+}
+
+func (r *repo) GetGlossaryTermByID(novelID string, termID string) (*models.GlossaryTerm, error) {
+	query := `
+		SELECT ` + glossaryTermColumns + `
+		FROM glossary_terms
+		WHERE novel_id = ? AND id = ?
+	`
+
+	row := r.db.QueryRow(query, novelID, termID)
+	return models.ScanGlossaryTerm(row)
+}
+
+func (r *repo) GetGlossaryTermByOriginal(novelID string, original string) (*models.GlossaryTerm, error) {
+	query := `
+		SELECT ` + glossaryTermColumns + `
+		FROM glossary_terms
+		WHERE novel_id = ? AND original = ?
+	`
+
+	row := r.db.QueryRow(query, novelID, original)
+	return models.ScanGlossaryTerm(row)
+}
+
+func (r *repo) CreateGlossaryTerm(term *models.GlossaryTerm) (*models.GlossaryTerm, error) {
+	// Generate a new UUID if not provided
+	if term.ID == "" {
+		term.ID = uuid.New().String()
+	}
+
+	query := `
+		INSERT INTO glossary_terms (` + glossaryTermColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(
+		query,
+		term.ID,
+		term.NovelID,
+		term.Original,
+		term.Translated,
+		term.Category,
+		term.Notes,
+		term.AutoExtracted,
+		term.ConflictingTranslation,
+		term.ConflictChapterNumber,
+		term.DateAdded,
+		term.LastUpdated,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return term, nil
+}
+
+func (r *repo) UpdateGlossaryTerm(term *models.GlossaryTerm) error {
+	query := `
+		UPDATE glossary_terms
+		SET original = ?, translated = ?, category = ?, notes = ?, auto_extracted = ?,
+		    conflicting_translation = ?, conflict_chapter_number = ?, last_updated = ?
+		WHERE id = ? AND novel_id = ?
+	`
+
+	result, err := r.db.Exec(
+		query,
+		term.Original,
+		term.Translated,
+		term.Category,
+		term.Notes,
+		term.AutoExtracted,
+		term.ConflictingTranslation,
+		term.ConflictChapterNumber,
+		term.LastUpdated,
+		term.ID,
+		term.NovelID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("glossary term not found")
+	}
+
+	return nil
+}
+
+func (r *repo) DeleteGlossaryTerm(novelID string, termID string) error {
+	query := `
+		DELETE FROM glossary_terms
+		WHERE novel_id = ? AND id = ?
+	`
+
+	result, err := r.db.Exec(query, novelID, termID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("glossary term not found")
+	}
+
+	return nil
+}
+
+// Story Context Operations
+
+func (r *repo) GetStoryContext(novelID string) (*models.StoryContext, error) {
+	query := `
+		SELECT novel_id, summary, last_chapter_number, last_updated
+		FROM story_contexts
+		WHERE novel_id = ?
+	`
+
+	row := r.db.QueryRow(query, novelID)
+	return models.ScanStoryContext(row)
+}
+
+func (r *repo) SaveStoryContext(storyContext *models.StoryContext) error {
+	query := `
+		INSERT INTO story_contexts (novel_id, summary, last_chapter_number, last_updated)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(novel_id) DO UPDATE SET
+			summary = excluded.summary,
+			last_chapter_number = excluded.last_chapter_number,
+			last_updated = excluded.last_updated
+	`
+
+	_, err := r.db.Exec(
+		query,
+		storyContext.NovelID,
+		storyContext.Summary,
+		storyContext.LastChapterNumber,
+		storyContext.LastUpdated,
+	)
+	return err
+}
+
+// Usage Operations
+
+func (r *repo) CreateLLMUsage(usage *models.LLMUsage) error {
+	// Generate a new UUID if not provided
+	if usage.ID == "" {
+		usage.ID = uuid.New().String()
+	}
+
+	query := `
+		INSERT INTO llm_usage (
+			id, novel_id, chapter_number, operation, provider, model, input_tokens, output_tokens,
+			cache_read_tokens, cache_write_tokens, cost, timestamp
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(
+		query,
+		usage.ID,
+		usage.NovelID,
+		usage.ChapterNumber,
+		usage.Operation,
+		usage.Provider,
+		usage.Model,
+		usage.InputTokens,
+		usage.OutputTokens,
+		usage.CacheReadTokens,
+		usage.CacheWriteTokens,
+		usage.Cost,
+		usage.Timestamp,
+	)
+	return err
+}
+
+// GetUsageSummaries aggregates the LLM usage since the given unix timestamp by "day", "novel" or "provider",
+// or into a single summary when groupBy is empty
+func (r *repo) GetUsageSummaries(groupBy string, since int64) ([]*models.UsageSummary, error) {
+	grouping, ok := usageGroupings[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid usage grouping: %s", groupBy)
+	}
+
+	query := `
+		SELECT ` + grouping.key + ` AS usage_key, ` + grouping.name + `,
+		       COUNT(*), COALESCE(SUM(u.input_tokens), 0), COALESCE(SUM(u.output_tokens), 0),
+		       COALESCE(SUM(u.cache_read_tokens), 0), COALESCE(SUM(u.cache_write_tokens), 0),
+		       COALESCE(SUM(u.cost), 0)
+		FROM llm_usage u
+		LEFT JOIN novels n ON n.id = u.novel_id
+		WHERE u.timestamp >= ?
+	`
+	if groupBy != "" {
+		query += `
+		GROUP BY usage_key
+		ORDER BY usage_key
+	`
+	}
+
+	rows, err := r.db.Query(query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return models.ScanUsageSummaries(rows)
+}
+
+// GetNovelUsageSummary aggregates all the LLM usage recorded for a novel
+func (r *repo) GetNovelUsageSummary(novelID string) (*models.UsageSummary, error) {
+	query := `
+		SELECT novel_id, '',
+		       COUNT(*), COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0),
+		       COALESCE(SUM(cache_read_tokens), 0), COALESCE(SUM(cache_write_tokens), 0),
+		       COALESCE(SUM(cost), 0)
+		FROM llm_usage
+		WHERE novel_id = ?
+		GROUP BY novel_id
+	`
+
+	rows, err := r.db.Query(query, novelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries, err := models.ScanUsageSummaries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(summaries) == 0 {
+		return &models.UsageSummary{Key: novelID}, nil
+	}
+
+	return summaries[0], nil
+}
+
+// LLM Cache Operations
+
+func (r *repo) GetLLMCacheEntry(key string) (*models.LLMCacheEntry, error) {
+	query := `
+		SELECT key, provider, model, operation, response, created_at
+		FROM llm_cache
+		WHERE key = ?
+	`
+
+	row := r.db.QueryRow(query, key)
+	return models.ScanLLMCacheEntry(row)
+}
+
+func (r *repo) SaveLLMCacheEntry(entry *models.LLMCacheEntry) error {
+	query := `
+		INSERT INTO llm_cache (key, provider, model, operation, response, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			response = excluded.response,
+			created_at = excluded.created_at
+	`
+
+	_, err := r.db.Exec(
+		query,
+		entry.Key,
+		entry.Provider,
+		entry.Model,
+		entry.Operation,
+		entry.Response,
+		entry.CreatedAt,
+	)
+	return err
+}
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"database/sql"
+	"fmt"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -61,6 +62,15 @@ func (s *SQLiteDB) QueryRow(query string, args ...interface{}) *sql.Row {
 	return s.db.QueryRow(query, args...)
 }
 
+// Begin starts a transaction
+func (s *SQLiteDB) Begin() (*sql.Tx, error) {
+	if s.closed {
+		return nil, sql.ErrConnDone
+	}
+
+	return s.db.Begin()
+}
+
 // Ping checks if the database connection is alive
 func (s *SQLiteDB) Ping() error {
 	if s.closed {
@@ -80,6 +90,29 @@ func (s *SQLiteDB) Close() error {
 	return s.db.Close()
 }
 
+// addedColumns lists the columns added after the initial schema, in the order they were introduced
+var addedColumns = []struct {
+	table      string
+	name       string
+	definition string
+}{
+	{"chapters", "provider", "TEXT NOT NULL DEFAULT ''"},
+	{"chapters", "attempts", "INTEGER NOT NULL DEFAULT 0"},
+	{"glossary_terms", "auto_extracted", "INTEGER NOT NULL DEFAULT 0"},
+	{"glossary_terms", "conflicting_translation", "TEXT NOT NULL DEFAULT ''"},
+	{"glossary_terms", "conflict_chapter_number", "INTEGER NOT NULL DEFAULT 0"},
+	{"chapters", "quality_score", "REAL"},
+	{"chapters", "quality_flags", "TEXT NOT NULL DEFAULT ''"},
+	{"novels", "target_language", "TEXT NOT NULL DEFAULT ''"},
+	{"chapters", "language", "TEXT NOT NULL DEFAULT 'English'"},
+	{"chapters", "version", "INTEGER NOT NULL DEFAULT 0"},
+	{"novels", "style", "TEXT NOT NULL DEFAULT ''"},
+	{"chapters", "style", "TEXT NOT NULL DEFAULT ''"},
+	{"chapters", "prompt_version", "TEXT NOT NULL DEFAULT ''"},
+	{"chapter_versions", "prompt_version", "TEXT NOT NULL DEFAULT ''"},
+	{"chapter_sources", "extracted", "INTEGER NOT NULL DEFAULT 0"},
+}
+
 // initSchema initializes the database schema if it doesn't exist
 func initSchema(db *sql.DB) error {
 	// Create novels table
@@ -99,7 +132,9 @@ func initSchema(db *sql.DB) error {
 			last_read_chapter_number INTEGER,
 			last_read_timestamp INTEGER,
 			last_updated INTEGER NOT NULL,
-			date_added INTEGER NOT NULL
+			date_added INTEGER NOT NULL,
+			target_language TEXT NOT NULL DEFAULT '',
+			style TEXT NOT NULL DEFAULT ''
 		);
 	`)
 	if err != nil {
@@ -118,7 +153,174 @@ func initSchema(db *sql.DB) error {
 			date_translated INTEGER NOT NULL,
 			word_count INTEGER,
 			url TEXT,
-			next_chapter_url TEXT,               
+			next_chapter_url TEXT,
+			provider TEXT NOT NULL DEFAULT '',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			quality_score REAL,
+			quality_flags TEXT NOT NULL DEFAULT '',
+			language TEXT NOT NULL DEFAULT 'English',
+			version INTEGER NOT NULL DEFAULT 0,
+			style TEXT NOT NULL DEFAULT '',
+			prompt_version TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY (novel_id) REFERENCES novels(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create glossary terms table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS glossary_terms (
+			id TEXT PRIMARY KEY,
+			novel_id TEXT NOT NULL,
+			original TEXT NOT NULL,
+			translated TEXT NOT NULL,
+			category TEXT NOT NULL DEFAULT '',
+			notes TEXT NOT NULL DEFAULT '',
+			auto_extracted INTEGER NOT NULL DEFAULT 0,
+			conflicting_translation TEXT NOT NULL DEFAULT '',
+			conflict_chapter_number INTEGER NOT NULL DEFAULT 0,
+			date_added INTEGER NOT NULL,
+			last_updated INTEGER NOT NULL,
+			FOREIGN KEY (novel_id) REFERENCES novels(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create story contexts table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS story_contexts (
+			novel_id TEXT PRIMARY KEY,
+			summary TEXT NOT NULL,
+			last_chapter_number INTEGER NOT NULL,
+			last_updated INTEGER NOT NULL,
+			FOREIGN KEY (novel_id) REFERENCES novels(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create LLM usage table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS llm_usage (
+			id TEXT PRIMARY KEY,
+			novel_id TEXT NOT NULL DEFAULT '',
+			chapter_number INTEGER NOT NULL DEFAULT 0,
+			operation TEXT NOT NULL DEFAULT '',
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL,
+			input_tokens INTEGER NOT NULL,
+			output_tokens INTEGER NOT NULL,
+			cache_read_tokens INTEGER NOT NULL DEFAULT 0,
+			cache_write_tokens INTEGER NOT NULL DEFAULT 0,
+			cost REAL NOT NULL DEFAULT 0,
+			timestamp INTEGER NOT NULL
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create LLM response cache table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS llm_cache (
+			key TEXT PRIMARY KEY,
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL,
+			operation TEXT NOT NULL,
+			response TEXT NOT NULL,
+			created_at INTEGER NOT NULL
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create chapter versions table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS chapter_versions (
+			id TEXT PRIMARY KEY,
+			chapter_id TEXT NOT NULL,
+			novel_id TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			original_title TEXT NOT NULL DEFAULT '',
+			content TEXT NOT NULL,
+			word_count INTEGER NOT NULL DEFAULT 0,
+			provider TEXT NOT NULL DEFAULT '',
+			model TEXT NOT NULL DEFAULT '',
+			style TEXT NOT NULL DEFAULT '',
+			prompt_version TEXT NOT NULL DEFAULT '',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			quality_score REAL,
+			quality_flags TEXT NOT NULL DEFAULT '',
+			date_translated INTEGER NOT NULL,
+			FOREIGN KEY (novel_id) REFERENCES novels(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create chapter sources table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS chapter_sources (
+			chapter_id TEXT PRIMARY KEY,
+			novel_id TEXT NOT NULL,
+			original_text TEXT NOT NULL,
+			source_hash TEXT NOT NULL,
+			extracted INTEGER NOT NULL DEFAULT 0,
+			date_scraped INTEGER NOT NULL,
+			FOREIGN KEY (novel_id) REFERENCES novels(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create chapter alignments table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS chapter_alignments (
+			chapter_id TEXT NOT NULL,
+			novel_id TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			paragraphs TEXT NOT NULL,  -- Stored as JSON string
+			date_aligned INTEGER NOT NULL,
+			PRIMARY KEY (chapter_id, version),
+			FOREIGN KEY (novel_id) REFERENCES novels(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create style presets table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS style_presets (
+			name TEXT PRIMARY KEY,
+			description TEXT NOT NULL DEFAULT '',
+			instructions TEXT NOT NULL,
+			date_added INTEGER NOT NULL,
+			last_updated INTEGER NOT NULL
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create table of contents table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS table_of_contents (
+			novel_id TEXT NOT NULL,
+			number INTEGER NOT NULL,
+			url TEXT NOT NULL,
+			original_title TEXT NOT NULL DEFAULT '',
+			date_scraped INTEGER NOT NULL,
+			PRIMARY KEY (novel_id, number),
 			FOREIGN KEY (novel_id) REFERENCES novels(id)
 		);
 	`)
@@ -126,11 +328,27 @@ func initSchema(db *sql.DB) error {
 		return err
 	}
 
+	// Add columns introduced after the initial schema to existing databases
+	for _, column := range addedColumns {
+		if err = addColumnIfNotExists(db, column.table, column.name, column.definition); err != nil {
+			return err
+		}
+	}
+
 	// Create indexes for better performance
 	_, err = db.Exec(`
 		CREATE INDEX IF NOT EXISTS idx_chapters_novel_id ON chapters(novel_id);
 		CREATE INDEX IF NOT EXISTS idx_chapters_number ON chapters(novel_id, number);
-		CREATE UNIQUE INDEX IF NOT EXISTS idx_chapters_url ON chapters(url);
+		DROP INDEX IF EXISTS idx_chapters_url;
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_chapters_url_language ON chapters(url, language);
+		CREATE INDEX IF NOT EXISTS idx_chapters_language ON chapters(novel_id, language, number);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_chapter_versions_version ON chapter_versions(chapter_id, version);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_glossary_terms_original ON glossary_terms(novel_id, original);
+
+		CREATE INDEX IF NOT EXISTS idx_llm_usage_timestamp ON llm_usage(timestamp);
+		CREATE INDEX IF NOT EXISTS idx_llm_usage_novel_id ON llm_usage(novel_id);
 
 		CREATE INDEX IF NOT EXISTS idx_novels_source ON novels(source);
 		CREATE INDEX IF NOT EXISTS idx_novels_last_read_timestamp ON novels(last_read_timestamp);
@@ -143,3 +361,35 @@ func initSchema(db *sql.DB) error {
 
 	return nil
 }
+
+// addColumnIfNotExists adds a column to an existing table unless it is already present
+func addColumnIfNotExists(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err = rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
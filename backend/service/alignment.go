@@ -0,0 +1,241 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/repo"
+	"backend/utils"
+)
+
+// Costs of the alignment moves in estimated tokens, on top of the tokens left unpaired or mismatched by the move.
+// Leaving a paragraph unpaired costs its whole length, so that long paragraphs are paired and only short lines
+// without counterpart, like the navigation of the page, are left out. Merging a paragraph into its neighbour also
+// costs its length, so that it is only merged when the translation is closer to the merged length than to the other.
+const (
+	alignMergeCost = 2
+	alignSkipCost  = 1
+)
+
+// alignMove pairs a number of original paragraphs with a number of translated ones
+type alignMove struct {
+	original   int
+	translated int
+}
+
+// alignMoves are the allowed moves: one to one, a paragraph on one side only, and two paragraphs merged into one
+var alignMoves = []alignMove{{1, 1}, {1, 0}, {0, 1}, {2, 1}, {1, 2}}
+
+// saveChapterAlignment aligns the active version of the chapter with its original text and stores the alignment
+func saveChapterAlignment(r repo.Repo, chapter *models.Chapter, originalText string) (*models.ChapterAlignment, error) {
+	alignment := &models.ChapterAlignment{
+		ChapterID:   chapter.ID,
+		NovelID:     chapter.NovelID,
+		Version:     chapter.Version,
+		Paragraphs:  alignParagraphs(splitLines(originalText), paragraphs(chapter.Content)),
+		DateAligned: time.Now().Unix(),
+	}
+
+	if err := r.SaveChapterAlignment(alignment); err != nil {
+		return nil, err
+	}
+
+	return alignment, nil
+}
+
+// GetBilingualChapter returns the chapter, in the language or the language of the novel when none is given,
+// paragraph by paragraph with its original. Chapters translated before the alignments were stored are aligned
+// on first request, provided their original was kept.
+func (s *novelService) GetBilingualChapter(novelID string, chapterNumber int, language string) (*models.BilingualChapter, error) {
+	if novelID == "" {
+		return nil, errors.New("novel ID cannot be empty")
+	}
+
+	if chapterNumber <= 0 {
+		return nil, errors.New("chapter number must be positive")
+	}
+
+	novel, err := s.repo.GetNovelByID(novelID)
+	if err != nil {
+		return nil, err
+	}
+
+	chapter, err := s.repo.GetChapterByNumber(novelID, chapterNumber, resolveLanguage(novel, language))
+	if err != nil {
+		return nil, err
+	}
+
+	alignment, err := s.repo.GetChapterAlignment(novelID, chapter.ID, chapter.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		source, sourceErr := s.repo.GetChapterSource(novelID, chapter.ID)
+		if errors.Is(sourceErr, sql.ErrNoRows) {
+			return nil, errors.New("the original of this chapter was not kept, retranslate it to align it")
+		}
+		if sourceErr != nil {
+			return nil, sourceErr
+		}
+
+		alignment, err = saveChapterAlignment(s.repo, chapter, source.OriginalText)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.UpdateLastReadChapter(novelID, chapter.Number)
+
+	return &models.BilingualChapter{
+		ChapterID:     chapter.ID,
+		NovelID:       chapter.NovelID,
+		Number:        chapter.Number,
+		Language:      chapter.Language,
+		Version:       chapter.Version,
+		Title:         chapter.Title,
+		OriginalTitle: chapter.OriginalTitle,
+		Paragraphs:    alignment.Paragraphs,
+	}, nil
+}
+
+// splitLines returns the non-empty lines of a text with one paragraph per line
+func splitLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// alignParagraphs pairs the original paragraphs with the translated ones, keeping their order. Like sentence
+// aligners, it expects a translated paragraph to be about as long as its original once scaled by the length ratio
+// of the chapter, and finds the sequence of moves with the lowest total mismatch by dynamic programming.
+func alignParagraphs(original, translated []string) []*models.AlignedParagraph {
+	originalLengths := paragraphLengths(original)
+	translatedLengths := paragraphLengths(translated)
+
+	ratio := 1.0
+	if originalTotal, translatedTotal := sum(originalLengths), sum(translatedLengths); originalTotal > 0 && translatedTotal > 0 {
+		ratio = float64(translatedTotal) / float64(originalTotal)
+	}
+	moves := alignMovesWithRatio(originalLengths, translatedLengths, ratio)
+
+	// The navigation of the page skews the ratio of the whole chapter: align again with the ratio of the paragraphs
+	// paired one to one, as the merged ones may hold a line of navigation
+	originalPaired, translatedPaired := 0, 0
+	i, j := 0, 0
+	for _, move := range moves {
+		if move.original == 1 && move.translated == 1 {
+			originalPaired += sum(originalLengths[i : i+move.original])
+			translatedPaired += sum(translatedLengths[j : j+move.translated])
+		}
+		i, j = i+move.original, j+move.translated
+	}
+	if originalPaired > 0 && translatedPaired > 0 {
+		moves = alignMovesWithRatio(originalLengths, translatedLengths, float64(translatedPaired)/float64(originalPaired))
+	}
+
+	aligned := make([]*models.AlignedParagraph, 0, len(moves))
+	i, j = 0, 0
+	for _, move := range moves {
+		paragraph := &models.AlignedParagraph{
+			OriginalIDs: []int{},
+			Original:    strings.Join(original[i:i+move.original], "\n"),
+			Translated:  strings.Join(translated[j:j+move.translated], "\n"),
+		}
+		for id := i; id < i+move.original; id++ {
+			paragraph.OriginalIDs = append(paragraph.OriginalIDs, id)
+		}
+		aligned = append(aligned, paragraph)
+		i, j = i+move.original, j+move.translated
+	}
+
+	return aligned
+}
+
+// alignMovesWithRatio returns, in order, the moves aligning the paragraphs of the given lengths at the lowest cost
+func alignMovesWithRatio(originalLengths, translatedLengths []int, ratio float64) []alignMove {
+	// cost[i][j] is the lowest cost of aligning the first i original paragraphs with the first j translated ones,
+	// reached with moves[i][j]
+	cost := make([][]float64, len(originalLengths)+1)
+	moves := make([][]alignMove, len(originalLengths)+1)
+	for i := range cost {
+		cost[i] = make([]float64, len(translatedLengths)+1)
+		moves[i] = make([]alignMove, len(translatedLengths)+1)
+		for j := range cost[i] {
+			cost[i][j] = math.Inf(1)
+		}
+	}
+	cost[0][0] = 0
+
+	for i := 0; i <= len(originalLengths); i++ {
+		for j := 0; j <= len(translatedLengths); j++ {
+			for _, move := range alignMoves {
+				previousI, previousJ := i-move.original, j-move.translated
+				if previousI < 0 || previousJ < 0 || math.IsInf(cost[previousI][previousJ], 1) {
+					continue
+				}
+
+				moveCost := cost[previousI][previousJ] + alignCost(originalLengths[previousI:i], translatedLengths[previousJ:j], ratio)
+				if moveCost < cost[i][j] {
+					cost[i][j] = moveCost
+					moves[i][j] = move
+				}
+			}
+		}
+	}
+
+	// Walk the best moves back from the end
+	var path []alignMove
+	for i, j := len(originalLengths), len(translatedLengths); i > 0 || j > 0; {
+		move := moves[i][j]
+		path = append(path, move)
+		i, j = i-move.original, j-move.translated
+	}
+	for left, right := 0, len(path)-1; left < right; left, right = left+1, right-1 {
+		path[left], path[right] = path[right], path[left]
+	}
+
+	return path
+}
+
+// alignCost is the number of tokens the move leaves unpaired or mismatched, in translated tokens, plus the cost of
+// the move itself, for the lengths of the original and translated paragraphs it pairs
+func alignCost(originalLengths, translatedLengths []int, ratio float64) float64 {
+	expected := ratio * float64(sum(originalLengths))
+	translatedLength := float64(sum(translatedLengths))
+
+	switch {
+	case len(translatedLengths) == 0:
+		return expected + alignSkipCost
+	case len(originalLengths) == 0:
+		return translatedLength + alignSkipCost
+	case len(originalLengths) > 1:
+		return math.Abs(translatedLength-expected) + ratio*float64(slices.Min(originalLengths)) + alignMergeCost
+	case len(translatedLengths) > 1:
+		return math.Abs(translatedLength-expected) + float64(slices.Min(translatedLengths)) + alignMergeCost
+	default:
+		return math.Abs(translatedLength - expected)
+	}
+}
+
+// paragraphLengths measures the paragraphs in estimated tokens, which keeps CJK and alphabetic text comparable
+func paragraphLengths(paragraphs []string) []int {
+	lengths := make([]int, len(paragraphs))
+	for i, paragraph := range paragraphs {
+		lengths[i] = utils.EstimateTokens(paragraph)
+	}
+	return lengths
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"backend/models"
+)
+
+func TestAlignParagraphsOneToOne(t *testing.T) {
+	original := []string{"他走进了山门。", "师父站在大殿前面，看着远处的群山。", "你来晚了。"}
+	translated := []string{"He walked through the gate.", "His master stood before the great hall, looking at the distant mountains.", "You are late."}
+
+	aligned := alignParagraphs(original, translated)
+	if len(aligned) != 3 {
+		t.Fatalf("expected 3 paragraphs, got %d", len(aligned))
+	}
+	for i, paragraph := range aligned {
+		if !slices.Equal(paragraph.OriginalIDs, []int{i}) || paragraph.Original != original[i] || paragraph.Translated != translated[i] {
+			t.Errorf("paragraph %d is %v %q -> %q", i, paragraph.OriginalIDs, paragraph.Original, paragraph.Translated)
+		}
+	}
+}
+
+func TestAlignParagraphsMerged(t *testing.T) {
+	original := []string{"他走进了山门。", "师父站在大殿前面。", "师父看着远处的群山，很久没有说话。", "你来晚了。"}
+	translated := []string{"He walked through the gate.", "His master stood before the great hall and looked at the distant mountains for a long time without a word.", "You are late."}
+
+	aligned := alignParagraphs(original, translated)
+	if len(aligned) != 3 {
+		t.Fatalf("expected 3 paragraphs, got %d", len(aligned))
+	}
+	if !slices.Equal(aligned[1].OriginalIDs, []int{1, 2}) || aligned[1].Translated != translated[1] {
+		t.Errorf("expected the merged paragraphs to be paired together, got %v -> %q", aligned[1].OriginalIDs, aligned[1].Translated)
+	}
+	if !slices.Equal(aligned[2].OriginalIDs, []int{3}) || aligned[2].Translated != translated[2] {
+		t.Errorf("expected the last paragraphs to be paired, got %v -> %q", aligned[2].OriginalIDs, aligned[2].Translated)
+	}
+}
+
+func TestAlignParagraphsLeavesNavigationUnpaired(t *testing.T) {
+	original := []string{
+		"上一章",
+		"他走进了山门，看见师父站在大殿前面，看着远处的群山。",
+		"师父说：你来晚了，我已经等了你三天。",
+		"他低下头，没有说话。",
+		"天色渐渐暗了下来，山风吹过大殿前的松树，发出沙沙的声音。",
+		"师父叹了口气，转身走进了大殿。",
+		"下一章",
+	}
+	var translated []string
+	for _, paragraph := range original[1:6] {
+		translated = append(translated, fakeTranslation(paragraph))
+	}
+
+	for _, longerFirst := range []bool{false, true} {
+		// A paragraph translated a little longer than the others still leaves the navigation next to it out
+		if longerFirst {
+			translated[0] += " for a while"
+		}
+		assertNavigationUnpaired(t, alignParagraphs(original, translated))
+	}
+}
+
+func assertNavigationUnpaired(t *testing.T, aligned []*models.AlignedParagraph) {
+	t.Helper()
+
+	var paired [][]int
+	for _, paragraph := range aligned {
+		if paragraph.Translated == "" {
+			continue
+		}
+		paired = append(paired, paragraph.OriginalIDs)
+	}
+	if !slices.EqualFunc(paired, [][]int{{1}, {2}, {3}, {4}, {5}}, slices.Equal) {
+		t.Errorf("expected the chapter paragraphs alone to be paired, got %v", paired)
+	}
+
+	// Every original paragraph is kept, in order
+	var ids []int
+	for _, paragraph := range aligned {
+		ids = append(ids, paragraph.OriginalIDs...)
+	}
+	if !slices.Equal(ids, []int{0, 1, 2, 3, 4, 5, 6}) {
+		t.Errorf("expected every original paragraph in order, got %v", ids)
+	}
+}
+
+func TestAlignParagraphsEmptyTranslation(t *testing.T) {
+	aligned := alignParagraphs([]string{"他走进了山门。"}, nil)
+	if len(aligned) != 1 || aligned[0].Translated != "" || !slices.Equal(aligned[0].OriginalIDs, []int{0}) {
+		t.Errorf("expected the original paragraph alone, got %+v", aligned)
+	}
+}
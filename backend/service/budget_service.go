@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/models"
+	"backend/repo"
+	"backend/utils"
+)
+
+// ErrBudgetExceeded is returned instead of calling the LLM when a spending budget is used up
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// BudgetService enforces the configured LLM spending budgets
+type BudgetService interface {
+	CheckBudget(novelID string) error
+	GetBudgetStatuses(novelID string) ([]*models.BudgetStatus, error)
+}
+
+// budgetLimit is a spending limit in tokens and/or dollars. Zero values are unlimited.
+type budgetLimit struct {
+	tokens int
+	cost   float64
+}
+
+func (l budgetLimit) enabled() bool {
+	return l.tokens > 0 || l.cost > 0
+}
+
+type budgetService struct {
+	repo repo.Repo
+
+	daily   budgetLimit
+	monthly budgetLimit
+	novel   budgetLimit
+}
+
+var budgetServiceInstance BudgetService
+
+// NewBudgetService creates a new budget service with the limits configured in the environment
+func NewBudgetService(r repo.Repo) BudgetService {
+	return &budgetService{
+		repo: r,
+		daily: budgetLimit{
+			tokens: utils.GetEnvInt("BUDGET_DAILY_TOKENS", 0),
+			cost:   utils.GetEnvFloat("BUDGET_DAILY_USD", 0),
+		},
+		monthly: budgetLimit{
+			tokens: utils.GetEnvInt("BUDGET_MONTHLY_TOKENS", 0),
+			cost:   utils.GetEnvFloat("BUDGET_MONTHLY_USD", 0),
+		},
+		novel: budgetLimit{
+			tokens: utils.GetEnvInt("BUDGET_NOVEL_TOKENS", 0),
+			cost:   utils.GetEnvFloat("BUDGET_NOVEL_USD", 0),
+		},
+	}
+}
+
+// GetBudgetService returns the budget service instance
+func GetBudgetService() BudgetService {
+	return budgetServiceInstance
+}
+
+// CheckBudget returns ErrBudgetExceeded if the daily, monthly or novel budget is used up
+func (s *budgetService) CheckBudget(novelID string) error {
+	statuses, err := s.GetBudgetStatuses(novelID)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		if status.Exceeded {
+			return fmt.Errorf("%w: %s budget used up (%d tokens, $%.2f spent)", ErrBudgetExceeded, status.Scope, status.TokensUsed, status.CostUsed)
+		}
+	}
+
+	return nil
+}
+
+// GetBudgetStatuses returns the remaining daily and monthly budgets, and the novel budget when novelID is set.
+// Scopes without a configured limit are left out.
+func (s *budgetService) GetBudgetStatuses(novelID string) ([]*models.BudgetStatus, error) {
+	statuses := []*models.BudgetStatus{}
+	now := time.Now().UTC()
+
+	if s.daily.enabled() {
+		since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Unix()
+		status, err := s.periodStatus(models.BudgetScopeDaily, since, s.daily)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	if s.monthly.enabled() {
+		since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Unix()
+		status, err := s.periodStatus(models.BudgetScopeMonthly, since, s.monthly)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	if s.novel.enabled() && novelID != "" {
+		usage, err := s.repo.GetNovelUsageSummary(novelID)
+		if err != nil {
+			return nil, err
+		}
+		status := newBudgetStatus(models.BudgetScopeNovel, usage, s.novel)
+		status.NovelID = novelID
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (s *budgetService) periodStatus(scope string, since int64, limit budgetLimit) (*models.BudgetStatus, error) {
+	summaries, err := s.repo.GetUsageSummaries("", since)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &models.UsageSummary{}
+	if len(summaries) > 0 {
+		usage = summaries[0]
+	}
+
+	status := newBudgetStatus(scope, usage, limit)
+	status.Since = since
+	return status, nil
+}
+
+// newBudgetStatus compares the usage against a limit. All tokens sent to and received from the LLM count.
+func newBudgetStatus(scope string, usage *models.UsageSummary, limit budgetLimit) *models.BudgetStatus {
+	status := &models.BudgetStatus{
+		Scope:      scope,
+		TokenLimit: limit.tokens,
+		TokensUsed: usage.InputTokens + usage.OutputTokens + usage.CacheReadTokens + usage.CacheWriteTokens,
+		CostLimit:  limit.cost,
+		CostUsed:   usage.Cost,
+	}
+
+	if limit.tokens > 0 {
+		remaining := max(limit.tokens-status.TokensUsed, 0)
+		status.TokensRemaining = &remaining
+		status.Exceeded = status.Exceeded || remaining == 0
+	}
+	if limit.cost > 0 {
+		remaining := max(limit.cost-status.CostUsed, 0)
+		status.CostRemaining = &remaining
+		status.Exceeded = status.Exceeded || remaining == 0
+	}
+
+	return status
+}
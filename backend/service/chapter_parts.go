@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	"backend/models"
+	"backend/provider/llm"
+	"backend/utils"
+)
+
+// translateChapterContent translates a chapter page. When the text of the chapter is estimated over the
+// token budget, it is split into paragraph-aligned parts which are translated separately and stitched back together.
+func (s *translationService) translateChapterContent(ctx context.Context, llmClient llm.IClient, chapterContext *models.ChapterContext, htmlContent string) (*models.TranslatedChapter, error) {
+	text := utils.HTMLToText(htmlContent)
+	if utils.EstimateTokens(text) <= s.chunkTokenBudget {
+		return llmClient.TranslateNovelChapter(ctx, chapterContext, htmlContent)
+	}
+
+	chunks := utils.SplitIntoChunks(text, s.chunkTokenBudget)
+	log.Printf("Chapter is too long to translate at once, translating it in %d parts", len(chunks))
+
+	var parts []*models.TranslatedChapter
+	var err error
+	if s.chunkParallelism > 1 {
+		parts, err = translatePartsInParallel(ctx, llmClient, chapterContext, chunks, s.chunkParallelism)
+	} else {
+		parts, err = translatePartsSequentially(ctx, llmClient, chapterContext, chunks)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return stitchTranslatedParts(parts, s.chunkParallelism > 1), nil
+}
+
+// translatePartsSequentially translates the parts one after the other, so each part sees the end of the previous one
+func translatePartsSequentially(ctx context.Context, llmClient llm.IClient, chapterContext *models.ChapterContext, chunks []string) ([]*models.TranslatedChapter, error) {
+	parts := make([]*models.TranslatedChapter, len(chunks))
+	partContext := *chapterContext
+
+	for i, chunk := range chunks {
+		partContext.Part = i + 1
+		partContext.TotalParts = len(chunks)
+
+		part, err := llmClient.TranslateNovelChapter(ctx, &partContext, chunk)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = part
+
+		// The next part continues from this one
+		partContext.PreviousChapterTail = utils.TailText(utils.HTMLToText(part.TranslatedChapterContents), previousChapterTailLength)
+		if part.UpdatedStorySummary != "" {
+			partContext.StorySummary = part.UpdatedStorySummary
+		}
+	}
+
+	return parts, nil
+}
+
+// translatePartsInParallel translates up to parallelism parts at the same time. The first part updates the story
+// summary and the others summarize their own events. The first failure cancels the parts still being translated.
+func translatePartsInParallel(ctx context.Context, llmClient llm.IClient, chapterContext *models.ChapterContext, chunks []string, parallelism int) ([]*models.TranslatedChapter, error) {
+	parts := make([]*models.TranslatedChapter, len(chunks))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(parallelism)
+	for i, chunk := range chunks {
+		group.Go(func() error {
+			partContext := *chapterContext
+			partContext.Part = i + 1
+			partContext.TotalParts = len(chunks)
+			partContext.SummarizePartOnly = i > 0
+
+			part, err := llmClient.TranslateNovelChapter(groupCtx, &partContext, chunk)
+			if err != nil {
+				return err
+			}
+			parts[i] = part
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	return parts, nil
+}
+
+// stitchTranslatedParts joins the translated parts of a chapter into a single translated chapter
+// with the title of the first part. The story summary is the one of the last part, which saw the previous ones,
+// or when the parts were translated in parallel the summary of the first part followed by the events of the others.
+func stitchTranslatedParts(parts []*models.TranslatedChapter, parallel bool) *models.TranslatedChapter {
+	stitched := &models.TranslatedChapter{
+		TranslatedChapterTitle: parts[0].TranslatedChapterTitle,
+		OriginalChapterTitle:   parts[0].OriginalChapterTitle,
+	}
+
+	contents := make([]string, 0, len(parts))
+	var summaries, providers []string
+	for _, part := range parts {
+		contents = append(contents, part.TranslatedChapterContents)
+		stitched.PossibleNewGenres = append(stitched.PossibleNewGenres, part.PossibleNewGenres...)
+		stitched.NewGlossaryTerms = append(stitched.NewGlossaryTerms, part.NewGlossaryTerms...)
+		stitched.Attempts += max(part.Attempts, 1)
+		if part.UpdatedStorySummary != "" {
+			summaries = append(summaries, strings.TrimSpace(part.UpdatedStorySummary))
+		}
+		if part.Provider != "" && !slices.Contains(providers, part.Provider) {
+			providers = append(providers, part.Provider)
+		}
+	}
+
+	stitched.TranslatedChapterContents = strings.Join(contents, "\n")
+	stitched.PossibleNewGenres = utils.RemoveDuplicatesFromSlice(stitched.PossibleNewGenres)
+	stitched.Provider = strings.Join(providers, ", ")
+	if len(summaries) > 0 {
+		stitched.UpdatedStorySummary = summaries[len(summaries)-1]
+		if parallel {
+			stitched.UpdatedStorySummary = strings.Join(summaries, " ")
+		}
+	}
+
+	return stitched
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"backend/models"
+	"backend/provider/llm"
+	"backend/utils"
+)
+
+const chapterPartsTestSource = "<p>第一章 山门</p><p>他走进了山门。</p><p>师父站在大殿前面。</p><p>师父说你来晚了。</p><p>他低下头没有说话。</p><p>天色渐渐暗了下来。</p>"
+
+func TestTranslateChapterContentInParts(t *testing.T) {
+	for _, parallelism := range []int{1, 3} {
+		s := &translationService{chunkTokenBudget: 12, chunkParallelism: parallelism}
+
+		translatedChapter, err := s.translateChapterContent(context.Background(), llm.NewFakeClient(nil),
+			&models.ChapterContext{}, chapterPartsTestSource)
+		if err != nil {
+			t.Fatalf("Failed to translate with parallelism %d: %v", parallelism, err)
+		}
+
+		// Every paragraph is translated once and in order
+		paragraphs := strings.Split(utils.HTMLToText(translatedChapter.TranslatedChapterContents), "\n")
+		if len(paragraphs) != 6 {
+			t.Fatalf("expected 6 translated paragraphs with parallelism %d, got %d: %q", parallelism, len(paragraphs), paragraphs)
+		}
+		for i, original := range strings.Split(utils.HTMLToText(chapterPartsTestSource), "\n") {
+			if !strings.HasSuffix(paragraphs[i], fakeTranslation(original)) {
+				t.Errorf("paragraph %d with parallelism %d is %q, not the translation of %q", i, parallelism, paragraphs[i], original)
+			}
+		}
+
+		if translatedChapter.TranslatedChapterTitle == "" {
+			t.Errorf("expected the title of the first part with parallelism %d", parallelism)
+		}
+		if parts := len(utils.SplitIntoChunks(utils.HTMLToText(chapterPartsTestSource), 12)); parts < 2 || translatedChapter.Attempts != parts {
+			t.Errorf("expected an attempt per part with parallelism %d, got %d attempts for %d parts", parallelism, translatedChapter.Attempts, parts)
+		}
+	}
+}
+
+func TestTranslateChapterContentUnderBudget(t *testing.T) {
+	s := &translationService{chunkTokenBudget: 8000, chunkParallelism: 1}
+
+	translatedChapter, err := s.translateChapterContent(context.Background(), llm.NewFakeClient(nil),
+		&models.ChapterContext{}, chapterPartsTestSource)
+	if err != nil {
+		t.Fatalf("Failed to translate: %v", err)
+	}
+	wholeChapter, err := llm.NewFakeClient(nil).TranslateNovelChapter(context.Background(), &models.ChapterContext{}, chapterPartsTestSource)
+	if err != nil {
+		t.Fatalf("Failed to translate: %v", err)
+	}
+	if translatedChapter.TranslatedChapterContents != wholeChapter.TranslatedChapterContents {
+		t.Errorf("expected the chapter to be translated at once, got %q", translatedChapter.TranslatedChapterContents)
+	}
+}
+
+func TestTranslateChapterContentPartFails(t *testing.T) {
+	s := &translationService{chunkTokenBudget: 12, chunkParallelism: 1}
+
+	_, err := s.translateChapterContent(context.Background(), llm.NewFakeClient([]string{llm.FakeBehaviorOK, llm.FakeBehaviorRefused}),
+		&models.ChapterContext{}, chapterPartsTestSource)
+	if err == nil {
+		t.Fatal("expected the failure of a part to fail the chapter")
+	}
+}
+
+func TestTranslateChapterContentInPartsSummary(t *testing.T) {
+	chunks := len(utils.SplitIntoChunks(utils.HTMLToText(chapterPartsTestSource), 12))
+
+	for _, parallelism := range []int{1, 3} {
+		s := &translationService{chunkTokenBudget: 12, chunkParallelism: parallelism}
+
+		translatedChapter, err := s.translateChapterContent(context.Background(), llm.NewFakeClient(nil),
+			&models.ChapterContext{StorySummary: "Story so far."}, chapterPartsTestSource)
+		if err != nil {
+			t.Fatalf("Failed to translate with parallelism %d: %v", parallelism, err)
+		}
+
+		// The summary keeps the story so far once and the events of every part
+		summary := translatedChapter.UpdatedStorySummary
+		if strings.Count(summary, "Story so far.") != 1 || strings.Count(summary, "happened.") != chunks {
+			t.Errorf("expected the story so far and the events of %d parts with parallelism %d, got %q", chunks, parallelism, summary)
+		}
+	}
+}
+
+// countingClient counts the translations started before their context was cancelled
+type countingClient struct {
+	llm.IClient
+	calls atomic.Int32
+}
+
+func (c *countingClient) TranslateNovelChapter(ctx context.Context, chapterContext *models.ChapterContext, webpageContent string) (*models.TranslatedChapter, error) {
+	if ctx.Err() == nil {
+		c.calls.Add(1)
+	}
+	return c.IClient.TranslateNovelChapter(ctx, chapterContext, webpageContent)
+}
+
+func TestTranslateChapterContentInParallelCancelsOnFailure(t *testing.T) {
+	s := &translationService{chunkTokenBudget: 12, chunkParallelism: 2}
+	client := &countingClient{IClient: llm.NewFakeClient([]string{llm.FakeBehaviorFatal})}
+
+	if _, err := s.translateChapterContent(context.Background(), client, &models.ChapterContext{}, chapterPartsTestSource); err == nil {
+		t.Fatal("expected the failure of a part to fail the chapter")
+	}
+	if calls := client.calls.Load(); calls > 2 {
+		t.Errorf("expected the parts after the first failure to be cancelled, got %d translations", calls)
+	}
+}
+
+func TestStitchTranslatedPartsProviders(t *testing.T) {
+	stitched := stitchTranslatedParts([]*models.TranslatedChapter{
+		{TranslatedChapterTitle: "Chapter 1", Provider: "claude"},
+		{Provider: "gemini"},
+		{Provider: "claude"},
+	}, false)
+
+	if stitched.Provider != "claude, gemini" {
+		t.Errorf("expected the providers of every part, got %q", stitched.Provider)
+	}
+}
+
+// fakeTranslation is the translation of a line by the fake provider
+func fakeTranslation(line string) string {
+	translatedChapter, err := llm.NewFakeClient(nil).TranslateNovelChapter(context.Background(), &models.ChapterContext{}, "<p>"+line+"</p>")
+	if err != nil {
+		return ""
+	}
+	_, translation, _ := strings.Cut(utils.HTMLToText(translatedChapter.TranslatedChapterContents), " ")
+	return translation
+}
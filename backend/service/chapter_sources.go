@@ -0,0 +1,54 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"backend/models"
+	"backend/repo"
+	"backend/utils"
+)
+
+// pageHash returns the hash kept with the original of a chapter to tell whether its page changed since
+func pageHash(pageContent string) string {
+	hash := sha256.Sum256([]byte(pageContent))
+	return hex.EncodeToString(hash[:])
+}
+
+// saveChapterSource keeps the original text of a chapter, as extracted from its page, with a hash of the raw page
+func saveChapterSource(r repo.Repo, chapter *models.Chapter, pageContent, chapterContent string, extracted bool) error {
+	return r.SaveChapterSource(&models.ChapterSource{
+		ChapterID:    chapter.ID,
+		NovelID:      chapter.NovelID,
+		OriginalText: utils.HTMLToText(chapterContent),
+		SourceHash:   pageHash(pageContent),
+		Extracted:    extracted,
+		DateScraped:  time.Now().Unix(),
+	})
+}
+
+// IncludeOriginal loads the original of each chapter. Chapters translated before the originals were kept have none.
+func (s *novelService) IncludeOriginal(chapters ...*models.Chapter) error {
+	// Chapters are listed one novel at a time
+	chapterIDs := make(map[string][]string)
+	for _, chapter := range chapters {
+		chapterIDs[chapter.NovelID] = append(chapterIDs[chapter.NovelID], chapter.ID)
+	}
+
+	originals := make(map[string]*models.ChapterSource)
+	for novelID, ids := range chapterIDs {
+		sources, err := s.repo.GetChapterSources(novelID, ids)
+		if err != nil {
+			return err
+		}
+		for _, source := range sources {
+			originals[source.ChapterID] = source
+		}
+	}
+
+	for _, chapter := range chapters {
+		chapter.Original = originals[chapter.ID]
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"backend/models"
+	"backend/repo"
+	"backend/utils"
+)
+
+// ErrInvalidVersion is returned for chapter version numbers below 1
+var ErrInvalidVersion = errors.New("invalid chapter version")
+
+// newChapterVersion records the translation a chapter currently shows as its version
+func newChapterVersion(chapter *models.Chapter, model string) *models.ChapterVersion {
+	return &models.ChapterVersion{
+		ChapterID:      chapter.ID,
+		NovelID:        chapter.NovelID,
+		Version:        chapter.Version,
+		Title:          chapter.Title,
+		OriginalTitle:  chapter.OriginalTitle,
+		Content:        chapter.Content,
+		WordCount:      chapter.WordCount,
+		Provider:       chapter.Provider,
+		Model:          model,
+		Style:          chapter.Style,
+		PromptVersion:  chapter.PromptVersion,
+		Attempts:       chapter.Attempts,
+		QualityScore:   chapter.QualityScore,
+		QualityFlags:   chapter.QualityFlags,
+		DateTranslated: chapter.DateTranslated,
+	}
+}
+
+// recordChapterVersion keeps the translation the chapter currently shows as one of its versions
+func recordChapterVersion(r repo.Repo, chapter *models.Chapter, model string) error {
+	_, err := r.CreateChapterVersion(newChapterVersion(chapter, model))
+	return err
+}
+
+// GetChapterVersions lists the translations of a chapter, without their content.
+// Chapters translated before versions were kept have none until they are retranslated.
+func (s *novelService) GetChapterVersions(novelID string, chapterID string) ([]*models.ChapterVersion, error) {
+	if novelID == "" {
+		return nil, errors.New("novel ID cannot be empty")
+	}
+
+	if chapterID == "" {
+		return nil, errors.New("chapter ID cannot be empty")
+	}
+
+	chapter, err := s.repo.GetChapterByID(novelID, chapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.repo.GetChapterVersions(novelID, chapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, version := range versions {
+		version.Active = version.Version == chapter.Version
+	}
+
+	return versions, nil
+}
+
+// SetActiveChapterVersion makes the chapter show one of its versions
+func (s *novelService) SetActiveChapterVersion(novelID string, chapterID string, versionNumber int) (*models.Chapter, error) {
+	if novelID == "" {
+		return nil, errors.New("novel ID cannot be empty")
+	}
+
+	if chapterID == "" {
+		return nil, errors.New("chapter ID cannot be empty")
+	}
+
+	if versionNumber < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidVersion, versionNumber)
+	}
+
+	chapter, err := s.repo.GetChapterByID(novelID, chapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := s.repo.GetChapterVersion(novelID, chapterID, versionNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	chapter.Version = version.Version
+	chapter.Title = version.Title
+	chapter.OriginalTitle = version.OriginalTitle
+	chapter.Content = version.Content
+	chapter.WordCount = version.WordCount
+	chapter.Provider = version.Provider
+	chapter.Attempts = version.Attempts
+	chapter.QualityScore = version.QualityScore
+	chapter.QualityFlags = version.QualityFlags
+	chapter.Style = version.Style
+	chapter.PromptVersion = version.PromptVersion
+	chapter.DateTranslated = version.DateTranslated
+
+	if err = s.repo.UpdateChapter(chapter); err != nil {
+		return nil, err
+	}
+
+	return chapter, nil
+}
+
+// DiffChapterVersions compares the paragraphs of two versions of a chapter
+func (s *novelService) DiffChapterVersions(novelID string, chapterID string, from, to int) (*models.ChapterDiff, error) {
+	if novelID == "" {
+		return nil, errors.New("novel ID cannot be empty")
+	}
+
+	if chapterID == "" {
+		return nil, errors.New("chapter ID cannot be empty")
+	}
+
+	if from < 1 || to < 1 {
+		return nil, fmt.Errorf("%w: %d to %d", ErrInvalidVersion, from, to)
+	}
+
+	fromVersion, err := s.repo.GetChapterVersion(novelID, chapterID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toVersion, err := s.repo.GetChapterVersion(novelID, chapterID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ChapterDiff{
+		ChapterID: chapterID,
+		From:      from,
+		To:        to,
+		Changes:   diffParagraphs(paragraphs(fromVersion.Content), paragraphs(toVersion.Content)),
+	}, nil
+}
+
+// paragraphs returns the text of each paragraph of an HTML chapter
+func paragraphs(content string) []string {
+	return splitLines(utils.HTMLToText(content))
+}
+
+// diffParagraphs lists the paragraphs kept, removed and added to go from one version to the other,
+// based on their longest common subsequence
+func diffParagraphs(from, to []string) []*models.DiffChange {
+	// common[i][j] is the length of the longest common subsequence of from[i:] and to[j:]
+	common := make([][]int, len(from)+1)
+	for i := range common {
+		common[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	changes := []*models.DiffChange{}
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			changes = append(changes, &models.DiffChange{Op: models.DiffOpEqual, Text: from[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			changes = append(changes, &models.DiffChange{Op: models.DiffOpDelete, Text: from[i]})
+			i++
+		default:
+			changes = append(changes, &models.DiffChange{Op: models.DiffOpInsert, Text: to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		changes = append(changes, &models.DiffChange{Op: models.DiffOpDelete, Text: from[i]})
+	}
+	for ; j < len(to); j++ {
+		changes = append(changes, &models.DiffChange{Op: models.DiffOpInsert, Text: to[j]})
+	}
+
+	return changes
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/repo"
+)
+
+// GlossaryService provides business logic for the per-novel glossary of names and terms
+type GlossaryService interface {
+	GetGlossary(novelID string) ([]*models.GlossaryTerm, error)
+	GetGlossaryConflicts(novelID string) ([]*models.GlossaryTerm, error)
+	CreateGlossaryTerm(term *models.GlossaryTerm) (*models.GlossaryTerm, error)
+	UpdateGlossaryTerm(request *models.GlossaryTermUpdateRequest) (*models.GlossaryTerm, error)
+	DeleteGlossaryTerm(novelID string, termID string) error
+	MergeExtractedTerms(novelID string, chapterNumber int, extractedTerms []*models.ExtractedGlossaryTerm) error
+}
+
+type glossaryService struct {
+	repo repo.Repo
+}
+
+var glossaryServiceInstance GlossaryService
+
+// NewGlossaryService creates a new glossary service
+func NewGlossaryService(r repo.Repo) GlossaryService {
+	return &glossaryService{
+		repo: r,
+	}
+}
+
+// GetGlossaryService returns the glossary service instance
+func GetGlossaryService() GlossaryService {
+	return glossaryServiceInstance
+}
+
+func (s *glossaryService) GetGlossary(novelID string) ([]*models.GlossaryTerm, error) {
+	if novelID == "" {
+		return nil, errors.New("novel ID cannot be empty")
+	}
+
+	// Check if novel exists
+	_, err := s.repo.GetNovelByID(novelID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetGlossaryTerms(novelID)
+}
+
+// GetGlossaryConflicts returns the glossary terms a chapter translated differently than the glossary
+func (s *glossaryService) GetGlossaryConflicts(novelID string) ([]*models.GlossaryTerm, error) {
+	terms, err := s.GetGlossary(novelID)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []*models.GlossaryTerm
+	for _, term := range terms {
+		if term.ConflictingTranslation != "" {
+			conflicts = append(conflicts, term)
+		}
+	}
+
+	return conflicts, nil
+}
+
+func (s *glossaryService) CreateGlossaryTerm(term *models.GlossaryTerm) (*models.GlossaryTerm, error) {
+	if err := validateGlossaryTerm(term); err != nil {
+		return nil, err
+	}
+
+	// Check if novel exists
+	_, err := s.repo.GetNovelByID(term.NovelID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if the term is already in the glossary
+	existingTerm, err := s.repo.GetGlossaryTermByOriginal(term.NovelID, term.Original)
+	if err == nil && existingTerm != nil {
+		return nil, errors.New("term already exists in the glossary")
+	}
+
+	term.ID = ""
+	term.DateAdded = time.Now().Unix()
+	term.LastUpdated = term.DateAdded
+
+	return s.repo.CreateGlossaryTerm(term)
+}
+
+func (s *glossaryService) UpdateGlossaryTerm(request *models.GlossaryTermUpdateRequest) (*models.GlossaryTerm, error) {
+	term := &request.GlossaryTerm
+	if term.ID == "" {
+		return nil, errors.New("glossary term ID cannot be empty")
+	}
+
+	if err := validateGlossaryTerm(term); err != nil {
+		return nil, err
+	}
+
+	// Check if the term exists
+	existingTerm, err := s.repo.GetGlossaryTermByID(term.NovelID, term.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// If changing the original term, check that it is not already in the glossary
+	if existingTerm.Original != term.Original {
+		checkTerm, err := s.repo.GetGlossaryTermByOriginal(term.NovelID, term.Original)
+		if err == nil && checkTerm != nil && checkTerm.ID != term.ID {
+			return nil, errors.New("term already exists in the glossary")
+		}
+	}
+
+	term.DateAdded = existingTerm.DateAdded
+	term.LastUpdated = time.Now().Unix()
+	term.AutoExtracted = existingTerm.AutoExtracted
+	if request.AutoExtracted != nil {
+		term.AutoExtracted = *request.AutoExtracted
+	}
+
+	if err = s.repo.UpdateGlossaryTerm(term); err != nil {
+		return nil, err
+	}
+
+	return term, nil
+}
+
+func (s *glossaryService) DeleteGlossaryTerm(novelID string, termID string) error {
+	if novelID == "" {
+		return errors.New("novel ID cannot be empty")
+	}
+
+	if termID == "" {
+		return errors.New("glossary term ID cannot be empty")
+	}
+
+	return s.repo.DeleteGlossaryTerm(novelID, termID)
+}
+
+// MergeExtractedTerms adds the terms the LLM introduced while translating a chapter to the novel's glossary.
+// Terms already in the glossary keep their translation; if the chapter translated one of them differently,
+// the term is flagged with the conflicting translation so it can be reviewed.
+func (s *glossaryService) MergeExtractedTerms(novelID string, chapterNumber int, extractedTerms []*models.ExtractedGlossaryTerm) error {
+	if len(extractedTerms) == 0 {
+		return nil
+	}
+
+	existingTerms, err := s.repo.GetGlossaryTerms(novelID)
+	if err != nil {
+		return err
+	}
+
+	glossary := make(map[string]*models.GlossaryTerm, len(existingTerms))
+	for _, term := range existingTerms {
+		glossary[term.Original] = term
+	}
+
+	var errs []error
+	for _, extractedTerm := range extractedTerms {
+		if extractedTerm == nil {
+			continue
+		}
+
+		original := strings.TrimSpace(extractedTerm.Original)
+		translated := strings.TrimSpace(extractedTerm.Translated)
+		if original == "" || translated == "" {
+			continue
+		}
+
+		existingTerm, ok := glossary[original]
+		if !ok {
+			term := &models.GlossaryTerm{
+				NovelID:       novelID,
+				Original:      original,
+				Translated:    translated,
+				Category:      strings.ToLower(strings.TrimSpace(extractedTerm.Category)),
+				AutoExtracted: true,
+				DateAdded:     time.Now().Unix(),
+				LastUpdated:   time.Now().Unix(),
+			}
+			if _, err = s.repo.CreateGlossaryTerm(term); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			glossary[original] = term
+			continue
+		}
+
+		if strings.EqualFold(existingTerm.Translated, translated) || strings.EqualFold(existingTerm.ConflictingTranslation, translated) {
+			continue
+		}
+
+		existingTerm.ConflictingTranslation = translated
+		existingTerm.ConflictChapterNumber = chapterNumber
+		existingTerm.LastUpdated = time.Now().Unix()
+		if err = s.repo.UpdateGlossaryTerm(existingTerm); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateGlossaryTerm trims the term and checks its required fields
+func validateGlossaryTerm(term *models.GlossaryTerm) error {
+	term.Original = strings.TrimSpace(term.Original)
+	term.Translated = strings.TrimSpace(term.Translated)
+	term.Category = strings.TrimSpace(term.Category)
+
+	if term.NovelID == "" {
+		return errors.New("novel ID cannot be empty")
+	}
+
+	if term.Original == "" {
+		return errors.New("original term cannot be empty")
+	}
+
+	if term.Translated == "" {
+		return errors.New("translated term cannot be empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"os"
+
+	"backend/models"
+)
+
+// defaultLanguage is the language novels are translated into unless they override it
+var defaultLanguage = defaultTranslationLanguage()
+
+// defaultTranslationLanguage reads the globally configured target language, English by default
+func defaultTranslationLanguage() string {
+	if language := models.NormalizeLanguage(os.Getenv("TRANSLATION_LANGUAGE")); language != "" {
+		return language
+	}
+	return models.LegacyLanguage
+}
+
+// novelLanguage returns the language the novel is translated into
+func novelLanguage(novel *models.Novel) string {
+	if novel.TargetLanguage != "" {
+		return novel.TargetLanguage
+	}
+	return defaultLanguage
+}
+
+// resolveLanguage returns the requested language, or the language of the novel when none is requested
+func resolveLanguage(novel *models.Novel, requested string) string {
+	if language := models.NormalizeLanguage(requested); language != "" {
+		return language
+	}
+	return novelLanguage(novel)
+}
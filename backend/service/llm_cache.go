@@ -0,0 +1,37 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"backend/models"
+	"backend/repo"
+)
+
+// llmResponseCache stores the LLM responses in the database
+type llmResponseCache struct {
+	repo repo.Repo
+}
+
+func (c *llmResponseCache) GetResponse(key string) (string, bool, error) {
+	entry, err := c.repo.GetLLMCacheEntry(key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return entry.Response, true, nil
+}
+
+func (c *llmResponseCache) SaveResponse(key, provider, model, operation, response string) error {
+	return c.repo.SaveLLMCacheEntry(&models.LLMCacheEntry{
+		Key:       key,
+		Provider:  provider,
+		Model:     model,
+		Operation: operation,
+		Response:  response,
+		CreatedAt: time.Now().Unix(),
+	})
+}
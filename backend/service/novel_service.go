@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"backend/models"
+	"backend/provider/sources"
 	"backend/repo"
 	"backend/utils"
 )
@@ -18,32 +19,40 @@ type NovelService interface {
 	SearchNovel(query string) ([]*models.Novel, error)
 	GetNovelsByFilter(filter, value string, offset, limit int) (*models.NovelListResponse, error)
 	UpdateNovel(novel *models.Novel) error
+	SetNovelLanguage(novelID string, language string) (*models.Novel, error)
+	SetNovelStyle(novelID string, style string) (*models.Novel, error)
 	DeleteNovel(id string) error
 
-	GetNovelChapters(novelID string) ([]*models.Chapter, error)
+	GetNovelChapters(novelID string, language string, includeUntranslated bool) ([]*models.Chapter, error)
 	GetChapterByID(novelID string, chapterID string) (*models.Chapter, error)
-	GetChapterByNumber(novelID string, chapterNumber int) (*models.Chapter, error)
+	GetChapterByNumber(novelID string, chapterNumber int, language string) (*models.Chapter, error)
+	GetBilingualChapter(novelID string, chapterNumber int, language string) (*models.BilingualChapter, error)
+	GetChapterURL(novelID string, chapterNumber int) (*models.ChapterURLResponse, error)
 	CreateChapter(chapter *models.Chapter) (*models.Chapter, error)
 	UpdateChapter(chapter *models.Chapter) error
 	DeleteChapter(novelID string, chapterID string) error
+	IncludeOriginal(chapters ...*models.Chapter) error
+
+	GetChapterVersions(novelID string, chapterID string) ([]*models.ChapterVersion, error)
+	SetActiveChapterVersion(novelID string, chapterID string, version int) (*models.Chapter, error)
+	DiffChapterVersions(novelID string, chapterID string, from, to int) (*models.ChapterDiff, error)
 
 	GetAllSources() ([]*models.SourceSite, error)
+	ResolveSource(url string) (*models.SourceResolution, error)
 }
 
 type novelService struct {
-	repo repo.Repo
+	repo   repo.Repo
+	styles StyleService
 }
 
 var novelServiceInstance NovelService
 
-func init() {
-	novelServiceInstance = NewNovelService(repo.GetRepo())
-}
-
 // NewNovelService creates a new novel service
 func NewNovelService(r repo.Repo) NovelService {
 	return &novelService{
-		repo: r,
+		repo:   r,
+		styles: NewStyleService(r),
 	}
 }
 
@@ -193,6 +202,54 @@ func (s *novelService) UpdateNovel(novel *models.Novel) error {
 	return s.repo.UpdateNovel(novel)
 }
 
+// SetNovelLanguage changes the language the novel is translated into. An empty language resets it to the global setting.
+// Chapters already translated are kept in their language.
+func (s *novelService) SetNovelLanguage(novelID string, language string) (*models.Novel, error) {
+	if novelID == "" {
+		return nil, errors.New("novel ID cannot be empty")
+	}
+
+	novel, err := s.repo.GetNovelByID(novelID)
+	if err != nil {
+		return nil, err
+	}
+
+	novel.TargetLanguage = models.NormalizeLanguage(language)
+	if err = s.repo.UpdateNovel(novel); err != nil {
+		return nil, err
+	}
+
+	return novel, nil
+}
+
+// SetNovelStyle changes the style preset the novel is translated in. An empty style resets it to the global setting.
+// Chapters already translated keep the style they were translated in until they are retranslated.
+func (s *novelService) SetNovelStyle(novelID string, style string) (*models.Novel, error) {
+	if novelID == "" {
+		return nil, errors.New("novel ID cannot be empty")
+	}
+
+	novel, err := s.repo.GetNovelByID(novelID)
+	if err != nil {
+		return nil, err
+	}
+
+	novel.Style = ""
+	if style != "" {
+		preset, err := s.styles.GetStylePreset(style)
+		if err != nil {
+			return nil, err
+		}
+		novel.Style = preset.Name
+	}
+
+	if err = s.repo.UpdateNovel(novel); err != nil {
+		return nil, err
+	}
+
+	return novel, nil
+}
+
 func (s *novelService) DeleteNovel(id string) error {
 	if id == "" {
 		return errors.New("novel ID cannot be empty")
@@ -203,18 +260,31 @@ func (s *novelService) DeleteNovel(id string) error {
 
 // Chapter operations
 
-func (s *novelService) GetNovelChapters(novelID string) ([]*models.Chapter, error) {
+// GetNovelChapters lists the chapters translated into the language, or into the language of the novel when none is given.
+// With includeUntranslated, the chapters of the table of contents not translated yet are listed along with them.
+func (s *novelService) GetNovelChapters(novelID string, language string, includeUntranslated bool) ([]*models.Chapter, error) {
 	if novelID == "" {
 		return nil, errors.New("novel ID cannot be empty")
 	}
 
 	// Check if novel exists
-	_, err := s.repo.GetNovelByID(novelID)
+	novel, err := s.repo.GetNovelByID(novelID)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.repo.GetNovelChapters(novelID)
+	language = resolveLanguage(novel, language)
+	chapters, err := s.repo.GetNovelChapters(novelID, language)
+	if err != nil || !includeUntranslated {
+		return chapters, err
+	}
+
+	entries, err := s.repo.GetTableOfContents(novelID)
+	if err != nil {
+		return nil, err
+	}
+
+	return withUntranslatedChapters(novel, chapters, entries, language), nil
 }
 
 func (s *novelService) GetChapterByID(novelID string, chapterID string) (*models.Chapter, error) {
@@ -235,7 +305,8 @@ func (s *novelService) GetChapterByID(novelID string, chapterID string) (*models
 	return chapter, err
 }
 
-func (s *novelService) GetChapterByNumber(novelID string, chapterNumber int) (*models.Chapter, error) {
+// GetChapterByNumber returns the chapter translated into the language, or into the language of the novel when none is given
+func (s *novelService) GetChapterByNumber(novelID string, chapterNumber int, language string) (*models.Chapter, error) {
 	if novelID == "" {
 		return nil, errors.New("novel ID cannot be empty")
 	}
@@ -244,7 +315,12 @@ func (s *novelService) GetChapterByNumber(novelID string, chapterNumber int) (*m
 		return nil, errors.New("chapter number must be positive")
 	}
 
-	chapter, err := s.repo.GetChapterByNumber(novelID, chapterNumber)
+	novel, err := s.repo.GetNovelByID(novelID)
+	if err != nil {
+		return nil, err
+	}
+
+	chapter, err := s.repo.GetChapterByNumber(novelID, chapterNumber, resolveLanguage(novel, language))
 
 	if chapter != nil {
 		_ = s.UpdateLastReadChapter(novelID, chapter.Number)
@@ -271,13 +347,14 @@ func (s *novelService) CreateChapter(chapter *models.Chapter) (*models.Chapter,
 	}
 
 	// Check if novel exists
-	_, err := s.repo.GetNovelByID(chapter.NovelID)
+	novel, err := s.repo.GetNovelByID(chapter.NovelID)
 	if err != nil {
 		return nil, err
 	}
+	chapter.Language = resolveLanguage(novel, chapter.Language)
 
 	// Check if chapter with the same number already exists
-	existingChapter, err := s.repo.GetChapterByNumber(chapter.NovelID, chapter.Number)
+	existingChapter, err := s.repo.GetChapterByNumber(chapter.NovelID, chapter.Number, chapter.Language)
 	if err == nil && existingChapter != nil {
 		return nil, errors.New("chapter with this number already exists")
 	}
@@ -326,9 +403,12 @@ func (s *novelService) UpdateChapter(chapter *models.Chapter) error {
 		return err
 	}
 
+	// A chapter stays in the language it was translated into
+	chapter.Language = existingChapter.Language
+
 	// If changing chapter number, check if the new number is already taken
 	if existingChapter.Number != chapter.Number {
-		checkChapter, err := s.repo.GetChapterByNumber(chapter.NovelID, chapter.Number)
+		checkChapter, err := s.repo.GetChapterByNumber(chapter.NovelID, chapter.Number, chapter.Language)
 		if err == nil && checkChapter != nil && checkChapter.ID != chapter.ID {
 			return errors.New("chapter with this number already exists")
 		}
@@ -378,71 +458,43 @@ func (s *novelService) UpdateLastReadChapter(novelID string, chapterNumber int)
 	return s.repo.UpdateLastReadChapter(novelID, chapterNumber)
 }
 
-// Source operations
+// GetChapterURL finds the URL of a chapter from its number, whether it is translated or not
+func (s *novelService) GetChapterURL(novelID string, chapterNumber int) (*models.ChapterURLResponse, error) {
+	novel, err := s.repo.GetNovelByID(novelID)
+	if err != nil {
+		return nil, err
+	}
+
+	novelSource, err := sources.GetSource(novel.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := chapterUrl(s.repo, novel, novelSource, chapterNumber)
+	if err != nil {
+		return nil, err
+	}
 
-var allSources = []*models.SourceSite{
-	{
-		ID:       "69shuba",
-		Name:     "69shuba",
-		URL:      "https://www.69shuba.com",
-		Language: "chinese",
-	},
-	{
-		ID:       "69yue",
-		Name:     "69yue",
-		URL:      "https://www.69yue.top",
-		Language: "chinese",
-	},
-	{
-		ID:       "shuhaige",
-		Name:     "shuhaige",
-		URL:      "https://m.shuhaige.net",
-		Language: "chinese",
-	},
-	{
-		ID:       "twkan",
-		Name:     "twkan",
-		URL:      "https://twkan.com",
-		Language: "chinese",
-	},
-	{
-		ID:       "doupo",
-		Name:     "doupo",
-		URL:      "https://doupo.935666.xyz",
-		Language: "chinese",
-	},
-	{
-		ID:       "ixdzs",
-		Name:     "ixdzs",
-		URL:      "https://ixdzs.tw",
-		Language: "chinese",
-	},
-	{
-		ID:       "czbooks",
-		Name:     "czbooks",
-		URL:      "https://czbooks.net",
-		Language: "chinese",
-	},
-	{
-		ID:       "quanben",
-		Name:     "quanben",
-		URL:      "https://www.quanben.io",
-		Language: "chinese",
-	},
-	{
-		ID:       "sjks88",
-		Name:     "sjks88",
-		URL:      "https://www.sjks88.com",
-		Language: "chinese",
-	},
-	{
-		ID:       "syosetu",
-		Name:     "syosetu",
-		URL:      "https://syosetu.com/",
-		Language: "japanese",
-	},
+	response := &models.ChapterURLResponse{URL: url}
+	if provider, ok := sources.GetChapterUrlPatternProvider(novelSource); ok {
+		response.URLPattern = provider.GetChapterUrlPattern(novel.URL)
+	}
+	return response, nil
 }
 
+// Source operations
+
+// GetAllSources lists the registered sources
 func (s *novelService) GetAllSources() ([]*models.SourceSite, error) {
-	return allSources, nil
+	return sources.GetSourceSites(), nil
+}
+
+// ResolveSource detects the source of a novel or chapter URL
+func (s *novelService) ResolveSource(url string) (*models.SourceResolution, error) {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return nil, errors.New("URL cannot be empty")
+	}
+
+	return sources.ResolveSource(url)
 }
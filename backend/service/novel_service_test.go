@@ -0,0 +1,39 @@
+package service
+
+import "testing"
+
+func TestGetNovelChaptersUntranslated(t *testing.T) {
+	s := newTestTranslationService(t)
+	novel := createTestNovel(t, s)
+	for _, number := range []int{1, 4} {
+		if _, err := translateTestChapter(s, novel.ID, number, ""); err != nil {
+			t.Fatalf("Failed to translate chapter %d: %v", number, err)
+		}
+	}
+	novels := NewNovelService(s.repo)
+
+	// Only the translated chapters are listed unless the untranslated ones are asked for
+	chapters, err := novels.GetNovelChapters(novel.ID, "", false)
+	if err != nil {
+		t.Fatalf("Failed to get the chapters: %v", err)
+	}
+	if len(chapters) != 2 || chapters[0].Number != 1 || chapters[1].Number != 4 {
+		t.Errorf("expected chapters 1 and 4, got %d chapters", len(chapters))
+	}
+
+	chapters, err = novels.GetNovelChapters(novel.ID, "", true)
+	if err != nil {
+		t.Fatalf("Failed to get the chapters: %v", err)
+	}
+	if len(chapters) != 4 {
+		t.Fatalf("expected chapters 1 to 4, got %d chapters", len(chapters))
+	}
+	for i, chapter := range chapters {
+		if chapter.Number != i+1 || chapter.Translated != (i == 0 || i == 3) {
+			t.Errorf("chapter %d is number %d, translated %v", i, chapter.Number, chapter.Translated)
+		}
+	}
+	if chapters[1].URL != "https://www.sjks88.com/xuanhuan/51964/2.html" {
+		t.Errorf("expected the URL of chapter 2 from the URL pattern, got %q", chapters[1].URL)
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"backend/models"
+	"backend/provider/llm"
+	"backend/utils"
+)
+
+// qualityReport is the result of the quality checks run on a translated chapter
+type qualityReport struct {
+	score          float64
+	flags          []string
+	lengthRatio    float64
+	cjkRatio       float64
+	paragraphRatio float64
+}
+
+func (r *qualityReport) passed() bool {
+	return len(r.flags) == 0
+}
+
+// feedback explains to the LLM why the previous translation was rejected
+func (r *qualityReport) feedback() string {
+	var problems []string
+	for _, flag := range r.flags {
+		switch flag {
+		case models.QualityFlagShortTranslation:
+			problems = append(problems, "it was much shorter than the original, so content was skipped or summarized")
+		case models.QualityFlagResidualCJK:
+			problems = append(problems, "parts of it were left in the source language")
+		case models.QualityFlagMissingParagraphs:
+			problems = append(problems, "it had far fewer paragraphs than the original")
+		case models.QualityFlagEmptyTitle:
+			problems = append(problems, "the chapter title was missing")
+		}
+	}
+	return strings.Join(problems, "; ")
+}
+
+// qualityGate checks translated chapters against their source
+type qualityGate struct {
+	// minLengthRatio is the lowest accepted ratio of translated to source tokens
+	minLengthRatio float64
+
+	// maxCJKRatio is the highest accepted share of CJK characters in the translation
+	maxCJKRatio float64
+
+	// minParagraphRatio is the lowest accepted ratio of translated to source paragraphs
+	minParagraphRatio float64
+
+	// minPageLengthRatio and minPageParagraphRatio replace the ratios above for the chapters translated from their
+	// whole page, whose source also counts the text left around the chapter
+	minPageLengthRatio    float64
+	minPageParagraphRatio float64
+
+	// maxRetries is how many times a translation failing the checks is retried
+	maxRetries int
+
+	// retryProvider, when set, is the provider used for the retries
+	retryProvider string
+}
+
+func newQualityGate() *qualityGate {
+	return &qualityGate{
+		minLengthRatio:    utils.GetEnvFloat("QUALITY_MIN_LENGTH_RATIO", 0.4),
+		maxCJKRatio:       utils.GetEnvFloat("QUALITY_MAX_CJK_RATIO", 0.05),
+		minParagraphRatio: utils.GetEnvFloat("QUALITY_MIN_PARAGRAPH_RATIO", 0.3),
+
+		minPageLengthRatio:    utils.GetEnvFloat("QUALITY_MIN_PAGE_LENGTH_RATIO", 0.25),
+		minPageParagraphRatio: utils.GetEnvFloat("QUALITY_MIN_PAGE_PARAGRAPH_RATIO", 0.15),
+
+		maxRetries:    utils.GetEnvNonNegativeInt("QUALITY_MAX_RETRIES", 1),
+		retryProvider: os.Getenv("QUALITY_RETRY_PROVIDER"),
+	}
+}
+
+// assess scores the translation between 0 and 1 and flags the checks it fails.
+// Residual CJK characters are not checked when translating into a CJK language. The length and paragraph ratios are
+// checked with the looser page thresholds when the source is a whole page rather than the extracted chapter, as the
+// text left around the chapter is not translated, and are not checked when the source has no text.
+func (g *qualityGate) assess(htmlContent string, extracted bool, language string, translatedChapter *models.TranslatedChapter) *qualityReport {
+	translatedText := utils.HTMLToText(translatedChapter.TranslatedChapterContents)
+	sourceText := utils.HTMLToText(htmlContent)
+	report := &qualityReport{
+		lengthRatio:    ratio(utils.EstimateTokens(translatedText), utils.EstimateTokens(sourceText)),
+		cjkRatio:       cjkRatio(translatedText),
+		paragraphRatio: ratio(countLines(translatedText), countLines(sourceText)),
+	}
+
+	minLengthRatio, minParagraphRatio := g.minLengthRatio, g.minParagraphRatio
+	if !extracted {
+		minLengthRatio, minParagraphRatio = g.minPageLengthRatio, g.minPageParagraphRatio
+	}
+
+	lengthScore := math.Min(1, report.lengthRatio/minLengthRatio)
+	if report.lengthRatio < minLengthRatio {
+		report.flags = append(report.flags, models.QualityFlagShortTranslation)
+	}
+
+	cjkScore := 1.0
+	if report.cjkRatio > g.maxCJKRatio && !models.IsCJKLanguage(language) {
+		report.flags = append(report.flags, models.QualityFlagResidualCJK)
+		cjkScore = 1 - report.cjkRatio
+	}
+
+	paragraphScore := math.Min(1, report.paragraphRatio/minParagraphRatio)
+	if report.paragraphRatio < minParagraphRatio {
+		report.flags = append(report.flags, models.QualityFlagMissingParagraphs)
+	}
+
+	titleScore := 1.0
+	if strings.TrimSpace(translatedChapter.TranslatedChapterTitle) == "" {
+		report.flags = append(report.flags, models.QualityFlagEmptyTitle)
+		titleScore = 0
+	}
+
+	report.score = math.Round((lengthScore+cjkScore+paragraphScore+titleScore)/4*100) / 100
+	return report
+}
+
+// translateWithQualityGate translates the chapter and checks the translation. A translation failing the checks is
+// retried with stricter instructions, with the retry provider when one is configured, and the best translation is kept.
+// extracted tells whether the content is the chapter alone rather than its whole page.
+func (s *translationService) translateWithQualityGate(ctx context.Context, novelID, provider string, llmClient llm.IClient, chapterContext *models.ChapterContext, htmlContent string, extracted bool) (*models.TranslatedChapter, *qualityReport, error) {
+	var best *models.TranslatedChapter
+	var bestReport *qualityReport
+	attempts := 0
+
+	for try := 0; try <= s.quality.maxRetries; try++ {
+		tryProvider, tryClient, tryContext := provider, llmClient, chapterContext
+		if try > 0 {
+			// Retries cost as much as the first translation
+			if err := s.budget.CheckBudget(novelID); err != nil {
+				log.Printf("Not retrying the translation: %v", err)
+				break
+			}
+
+			if s.quality.retryProvider != "" {
+				client, err := llm.GetClient(s.quality.retryProvider)
+				if err != nil {
+					log.Printf("Failed to get quality retry provider: %v", err)
+					break
+				}
+				tryProvider, tryClient = s.quality.retryProvider, client
+			}
+
+			stricterContext := *chapterContext
+			stricterContext.QualityFeedback = bestReport.feedback()
+			tryContext = &stricterContext
+		}
+
+		translatedContent, err := s.translateChapterContent(ctx, tryClient, tryContext, htmlContent)
+		if err != nil {
+			if best == nil {
+				return nil, nil, err
+			}
+			log.Printf("Quality retry failed, keeping the best translation: %v", err)
+			break
+		}
+
+		attempts += max(translatedContent.Attempts, 1)
+		if translatedContent.Provider == "" {
+			translatedContent.Provider = tryProvider
+		}
+
+		report := s.quality.assess(htmlContent, extracted, chapterContext.TargetLanguage, translatedContent)
+		if best == nil || report.score > bestReport.score {
+			best, bestReport = translatedContent, report
+		}
+		if report.passed() {
+			break
+		}
+		log.Printf("Translation failed the quality checks %v (score %.2f, length ratio %.2f, CJK ratio %.2f, paragraph ratio %.2f)",
+			report.flags, report.score, report.lengthRatio, report.cjkRatio, report.paragraphRatio)
+	}
+
+	if best == nil {
+		return nil, nil, errors.New("no translation attempt was made")
+	}
+
+	best.Attempts = attempts
+	return best, bestReport, nil
+}
+
+func ratio(value, reference int) float64 {
+	if reference == 0 {
+		return 1
+	}
+	return float64(value) / float64(reference)
+}
+
+func cjkRatio(text string) float64 {
+	cjk, total := 0, 0
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		total++
+		if utils.IsCJK(r) {
+			cjk++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(cjk) / float64(total)
+}
+
+func countLines(text string) int {
+	if text == "" {
+		return 0
+	}
+	return strings.Count(text, "\n") + 1
+}
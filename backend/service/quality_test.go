@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"backend/models"
+	"backend/provider/llm"
+)
+
+const qualityTestSource = "<p>第一章</p><p>他走进了山门，看见师父站在大殿前面。</p><p>师父说：你来晚了。</p><p>他低下头，没有说话。</p>"
+
+// qualityTestPage is the chapter with the text its page leaves around it once stripped
+const qualityTestPage = "<p>首页</p><p>玄幻小说</p>" + qualityTestSource + "<p>上一章</p><p>目录</p><p>下一章</p><p>本站域名请收藏，最新章节第一时间更新。</p>"
+
+const qualityTestTranslation = "<p>Chapter 1</p><p>He walked through the mountain gate and saw his master standing before the great hall.</p><p>The master said: you are late.</p><p>He lowered his head and said nothing.</p>"
+
+func testQualityGate() *qualityGate {
+	return &qualityGate{
+		minLengthRatio:    0.4,
+		maxCJKRatio:       0.05,
+		minParagraphRatio: 0.3,
+		maxRetries:        1,
+
+		minPageLengthRatio:    0.25,
+		minPageParagraphRatio: 0.15,
+	}
+}
+
+func TestQualityGateAssess(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		extracted  bool
+		language   string
+		title      string
+		contents   string
+		expected   []string
+		fullScored bool
+	}{
+		{
+			name:       "faithful translation",
+			extracted:  true,
+			title:      "Chapter 1",
+			contents:   qualityTestTranslation,
+			fullScored: true,
+		},
+		{
+			name:      "summarized translation",
+			extracted: true,
+			title:     "Chapter 1",
+			contents:  "<p>He came late.</p>",
+			expected:  []string{models.QualityFlagShortTranslation, models.QualityFlagMissingParagraphs},
+		},
+		{
+			name:     "summarized translation of a whole page",
+			source:   qualityTestPage,
+			title:    "Chapter 1",
+			contents: "<p>He came late.</p>",
+			expected: []string{models.QualityFlagShortTranslation, models.QualityFlagMissingParagraphs},
+		},
+		{
+			name:       "translation of a whole page without its navigation",
+			source:     qualityTestPage,
+			title:      "Chapter 1",
+			contents:   qualityTestTranslation,
+			fullScored: true,
+		},
+		{
+			name:       "source without text",
+			source:     "<div></div>",
+			title:      "Chapter 1",
+			contents:   "<p>He came late.</p>",
+			fullScored: true,
+		},
+		{
+			name:      "untranslated text",
+			extracted: true,
+			title:     "Chapter 1",
+			contents:  "<p>Chapter 1</p><p>他走进了山门，看见师父站在大殿前面。</p><p>The master said: you are late.</p><p>He lowered his head and said nothing.</p>",
+			expected:  []string{models.QualityFlagResidualCJK},
+		},
+		{
+			name:       "translation into a CJK language",
+			extracted:  true,
+			language:   "Japanese",
+			title:      "第一章",
+			contents:   "<p>第一章</p><p>彼は山門をくぐり、大殿の前に立つ師匠を見た。</p><p>師匠は言った：遅かったな。</p><p>彼はうつむいて、何も言わなかった。</p>",
+			fullScored: true,
+		},
+		{
+			name:      "missing title",
+			extracted: true,
+			contents:  qualityTestTranslation,
+			expected:  []string{models.QualityFlagEmptyTitle},
+		},
+	}
+
+	gate := testQualityGate()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := test.source
+			if source == "" {
+				source = qualityTestSource
+			}
+
+			report := gate.assess(source, test.extracted, test.language, &models.TranslatedChapter{
+				TranslatedChapterTitle:    test.title,
+				TranslatedChapterContents: test.contents,
+			})
+
+			if !slices.Equal(report.flags, test.expected) {
+				t.Errorf("expected flags %v, got %v", test.expected, report.flags)
+			}
+			if test.fullScored && report.score != 1 {
+				t.Errorf("expected a score of 1, got %.2f", report.score)
+			}
+			if !test.fullScored && report.score >= 1 {
+				t.Errorf("expected a score under 1, got %.2f", report.score)
+			}
+		})
+	}
+}
+
+func TestTranslateWithQualityGate(t *testing.T) {
+	s := newTestTranslationService(t)
+	s.quality = testQualityGate()
+
+	translatedChapter, report, err := s.translateWithQualityGate(context.Background(), "novel", llm.ProviderFake,
+		llm.NewFakeClient(nil), &models.ChapterContext{}, qualityTestSource, true)
+	if err != nil {
+		t.Fatalf("Failed to translate: %v", err)
+	}
+	if !report.passed() {
+		t.Errorf("expected the fake translation to pass the quality checks, got %v", report.flags)
+	}
+	if translatedChapter.Attempts != 1 || translatedChapter.Provider != llm.ProviderFake {
+		t.Errorf("expected a single attempt with the fake provider, got %d with %q", translatedChapter.Attempts, translatedChapter.Provider)
+	}
+}
+
+func TestTranslateWithQualityGateRetries(t *testing.T) {
+	s := newTestTranslationService(t)
+	s.quality = testQualityGate()
+	// No translation is long enough, so every attempt fails the checks
+	s.quality.minLengthRatio = 100
+	s.quality.maxRetries = 2
+
+	translatedChapter, report, err := s.translateWithQualityGate(context.Background(), "novel", llm.ProviderFake,
+		llm.NewFakeClient(nil), &models.ChapterContext{}, qualityTestSource, true)
+	if err != nil {
+		t.Fatalf("Failed to translate: %v", err)
+	}
+	if !slices.Equal(report.flags, []string{models.QualityFlagShortTranslation}) {
+		t.Errorf("expected the short translation flag, got %v", report.flags)
+	}
+	if translatedChapter.Attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", translatedChapter.Attempts)
+	}
+}
+
+func TestTranslateWithQualityGateKeepsBestTranslation(t *testing.T) {
+	s := newTestTranslationService(t)
+	s.quality = testQualityGate()
+	s.quality.minLengthRatio = 100
+
+	// The retry fails, and the translation failing the checks is kept rather than lost
+	translatedChapter, _, err := s.translateWithQualityGate(context.Background(), "novel", llm.ProviderFake,
+		llm.NewFakeClient([]string{llm.FakeBehaviorOK, llm.FakeBehaviorError}), &models.ChapterContext{}, qualityTestSource, true)
+	if err != nil {
+		t.Fatalf("Failed to translate: %v", err)
+	}
+	if translatedChapter.TranslatedChapterContents == "" || translatedChapter.Attempts != 1 {
+		t.Errorf("expected the first translation after 1 attempt, got %d attempts", translatedChapter.Attempts)
+	}
+}
+
+func TestTranslateWithQualityGateNegativeRetries(t *testing.T) {
+	t.Setenv("QUALITY_MAX_RETRIES", "-1")
+	s := newTestTranslationService(t)
+
+	translatedChapter, _, err := s.translateWithQualityGate(context.Background(), "novel", llm.ProviderFake,
+		llm.NewFakeClient(nil), &models.ChapterContext{}, qualityTestSource, true)
+	if err != nil {
+		t.Fatalf("Failed to translate: %v", err)
+	}
+	if translatedChapter.Attempts != 1 {
+		t.Errorf("expected a single attempt, got %d", translatedChapter.Attempts)
+	}
+}
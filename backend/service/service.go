@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"backend/models"
+	"backend/provider/llm"
+	"backend/repo"
+)
+
+// Init creates the service instances on the repository and connects the LLM providers to the response cache
+// and the usage statistics. It is called once at startup, after the database is opened.
+func Init(r repo.Repo) {
+	styleServiceInstance = NewStyleService(r)
+	budgetServiceInstance = NewBudgetService(r)
+	glossaryServiceInstance = NewGlossaryService(r)
+	novelServiceInstance = NewNovelService(r)
+	translationServiceInstance = NewTranslationService(r)
+	statsServiceInstance = NewStatsService(r)
+
+	llm.SetResponseCache(&llmResponseCache{repo: r})
+
+	// Record the usage of every LLM call
+	llm.SetUsageRecorder(func(ctx context.Context, usage *models.LLMUsage) {
+		if err := statsServiceInstance.RecordUsage(usage); err != nil {
+			log.Printf("Failed to record LLM usage: %v", err)
+		}
+	})
+}
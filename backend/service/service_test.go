@@ -0,0 +1,21 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+
+	"backend/repo"
+)
+
+// newTestTranslationService creates a translation service backed by a new database
+func newTestTranslationService(t *testing.T) *translationService {
+	t.Helper()
+
+	db, err := repo.NewSQLiteDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create the database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewTranslationService(repo.NewRepo(db)).(*translationService)
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"time"
+
 	"backend/models"
 	"backend/repo"
 )
@@ -8,22 +10,22 @@ import (
 // StatsService provides business logic for statistics operations
 type StatsService interface {
 	GetNovelStats() (*models.Stats, error)
+	GetUsageStats(days int, novelID string) (*models.UsageStats, error)
+	RecordUsage(usage *models.LLMUsage) error
 }
 
 type statsService struct {
-	repo repo.Repo
+	repo   repo.Repo
+	budget BudgetService
 }
 
 var statsServiceInstance StatsService
 
-func init() {
-	statsServiceInstance = NewStatsService(repo.GetRepo())
-}
-
 // NewStatsService creates a new instance of StatsService
 func NewStatsService(repo repo.Repo) StatsService {
 	return &statsService{
-		repo: repo,
+		repo:   repo,
+		budget: NewBudgetService(repo),
 	}
 }
 
@@ -44,3 +46,57 @@ func (s *statsService) GetNovelStats() (*models.Stats, error) {
 		ChapterCount: chapterCount,
 	}, nil
 }
+
+// GetUsageStats returns the LLM token usage and cost of the last days, in total and broken down per day, novel and provider
+func (s *statsService) GetUsageStats(days int, novelID string) (*models.UsageStats, error) {
+	if days <= 0 {
+		days = 30
+	}
+
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -(days - 1)).Unix()
+
+	totals, err := s.repo.GetUsageSummaries("", since)
+	if err != nil {
+		return nil, err
+	}
+
+	byDay, err := s.repo.GetUsageSummaries("day", since)
+	if err != nil {
+		return nil, err
+	}
+
+	byNovel, err := s.repo.GetUsageSummaries("novel", since)
+	if err != nil {
+		return nil, err
+	}
+
+	byProvider, err := s.repo.GetUsageSummaries("provider", since)
+	if err != nil {
+		return nil, err
+	}
+
+	budgets, err := s.budget.GetBudgetStatuses(novelID)
+	if err != nil {
+		return nil, err
+	}
+
+	total := &models.UsageSummary{}
+	if len(totals) > 0 {
+		total = totals[0]
+	}
+
+	return &models.UsageStats{
+		Since:      since,
+		Total:      total,
+		ByDay:      byDay,
+		ByNovel:    byNovel,
+		ByProvider: byProvider,
+		Budgets:    budgets,
+	}, nil
+}
+
+// RecordUsage stores the usage of a single LLM call
+func (s *statsService) RecordUsage(usage *models.LLMUsage) error {
+	return s.repo.CreateLLMUsage(usage)
+}
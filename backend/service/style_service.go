@@ -0,0 +1,206 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/repo"
+)
+
+// ErrUnknownStyle is returned when a translation asks for a style preset that does not exist
+var ErrUnknownStyle = errors.New("unknown style preset")
+
+// ErrInvalidStyle is returned for custom presets with an invalid name or without instructions
+var ErrInvalidStyle = errors.New("invalid style preset")
+
+// ErrStyleExists is returned when creating a custom preset under a name already taken
+var ErrStyleExists = errors.New("style preset already exists")
+
+// ErrBuiltInStyle is returned when changing or deleting a built-in preset
+var ErrBuiltInStyle = errors.New("built-in style presets cannot be changed")
+
+// stylePresetNamePattern restricts the names of custom presets to lowercase slugs
+var stylePresetNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// StyleService provides business logic for the translation style presets
+type StyleService interface {
+	GetStylePresets() ([]*models.StylePreset, error)
+	GetStylePreset(name string) (*models.StylePreset, error)
+	CreateStylePreset(preset *models.StylePreset) (*models.StylePreset, error)
+	UpdateStylePreset(preset *models.StylePreset) (*models.StylePreset, error)
+	DeleteStylePreset(name string) error
+}
+
+type styleService struct {
+	repo repo.Repo
+}
+
+// defaultStyle is the style preset of the novels that do not choose one
+var defaultStyle = defaultTranslationStyle()
+
+var styleServiceInstance StyleService
+
+// NewStyleService creates a new style service
+func NewStyleService(r repo.Repo) StyleService {
+	return &styleService{
+		repo: r,
+	}
+}
+
+// GetStyleService returns the style service instance
+func GetStyleService() StyleService {
+	return styleServiceInstance
+}
+
+// GetStylePresets lists the built-in presets followed by the custom ones
+func (s *styleService) GetStylePresets() ([]*models.StylePreset, error) {
+	customPresets, err := s.repo.GetStylePresets()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]*models.StylePreset{}, models.BuiltInStylePresets...), customPresets...), nil
+}
+
+func (s *styleService) GetStylePreset(name string) (*models.StylePreset, error) {
+	name = normalizeStyleName(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name cannot be empty", ErrInvalidStyle)
+	}
+
+	if preset := models.BuiltInStylePreset(name); preset != nil {
+		return preset, nil
+	}
+
+	preset, err := s.repo.GetStylePreset(name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStyle, name)
+	}
+	return preset, err
+}
+
+func (s *styleService) CreateStylePreset(preset *models.StylePreset) (*models.StylePreset, error) {
+	if err := validateStylePreset(preset); err != nil {
+		return nil, err
+	}
+
+	// Check if a preset with the same name already exists
+	_, err := s.GetStylePreset(preset.Name)
+	if err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrStyleExists, preset.Name)
+	}
+	if !errors.Is(err, ErrUnknownStyle) {
+		return nil, err
+	}
+
+	preset.BuiltIn = false
+	preset.DateAdded = time.Now().Unix()
+	preset.LastUpdated = time.Now().Unix()
+
+	return s.repo.CreateStylePreset(preset)
+}
+
+func (s *styleService) UpdateStylePreset(preset *models.StylePreset) (*models.StylePreset, error) {
+	if err := validateStylePreset(preset); err != nil {
+		return nil, err
+	}
+
+	// Check if the preset exists
+	existingPreset, err := s.GetStylePreset(preset.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	preset.BuiltIn = false
+	preset.DateAdded = existingPreset.DateAdded
+	preset.LastUpdated = time.Now().Unix()
+
+	if err = s.repo.UpdateStylePreset(preset); err != nil {
+		return nil, err
+	}
+
+	return preset, nil
+}
+
+// DeleteStylePreset deletes a custom preset. Novels still using it are translated in the default style.
+func (s *styleService) DeleteStylePreset(name string) error {
+	name = normalizeStyleName(name)
+	if name == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrInvalidStyle)
+	}
+
+	if models.BuiltInStylePreset(name) != nil {
+		return fmt.Errorf("%w: %s", ErrBuiltInStyle, name)
+	}
+
+	// Check if the preset exists
+	if _, err := s.GetStylePreset(name); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteStylePreset(name)
+}
+
+// defaultTranslationStyle reads the globally configured style preset, polished by default
+func defaultTranslationStyle() string {
+	if style := normalizeStyleName(os.Getenv("TRANSLATION_STYLE")); style != "" {
+		return style
+	}
+	return models.StylePolished
+}
+
+// resolveStyle returns the style preset of a translation: the requested one, else the one of the novel, else the
+// default one. An unknown requested preset is an error, while an unknown preset of the novel or of the configuration,
+// e.g. deleted since, falls back to polished.
+func resolveStyle(styles StyleService, novel *models.Novel, requested string) (*models.StylePreset, error) {
+	if normalizeStyleName(requested) != "" {
+		return styles.GetStylePreset(requested)
+	}
+
+	name := defaultStyle
+	if novel.Style != "" {
+		name = novel.Style
+	}
+
+	preset, err := styles.GetStylePreset(name)
+	if err != nil {
+		log.Printf("Failed to get style preset %s of novel %s, using %s: %v", name, novel.ID, models.StylePolished, err)
+		return models.BuiltInStylePreset(models.StylePolished), nil
+	}
+	return preset, nil
+}
+
+func normalizeStyleName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func validateStylePreset(preset *models.StylePreset) error {
+	preset.Name = normalizeStyleName(preset.Name)
+	preset.Description = strings.TrimSpace(preset.Description)
+	preset.Instructions = strings.TrimSpace(preset.Instructions)
+
+	if preset.Name == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrInvalidStyle)
+	}
+
+	if !stylePresetNamePattern.MatchString(preset.Name) {
+		return fmt.Errorf("%w: name must contain only lowercase letters, digits, dashes and underscores", ErrInvalidStyle)
+	}
+
+	if models.BuiltInStylePreset(preset.Name) != nil {
+		return fmt.Errorf("%w: %s", ErrBuiltInStyle, preset.Name)
+	}
+
+	if preset.Instructions == "" {
+		return fmt.Errorf("%w: instructions cannot be empty", ErrInvalidStyle)
+	}
+
+	return nil
+}
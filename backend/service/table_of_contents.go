@@ -0,0 +1,324 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"backend/models"
+	"backend/provider/sources"
+	"backend/provider/webscraper"
+	"backend/repo"
+	"backend/utils"
+)
+
+// maxTableOfContentsPages bounds the pages of a table of contents scraped at once
+const maxTableOfContentsPages = 100
+
+// ErrUnknownChapterURL is returned for a chapter number whose URL is neither in the table of contents of the novel
+// nor given by the chapter URL pattern of its source
+var ErrUnknownChapterURL = errors.New("chapter URL is unknown")
+
+// ErrChapterURLMismatch is returned for a chapter asked for by number with the URL of another chapter
+var ErrChapterURLMismatch = errors.New("chapter URL does not match the chapter number")
+
+// chapterUrl finds the URL of a chapter from its number, in the table of contents of the novel first
+func chapterUrl(r repo.Repo, novel *models.Novel, novelSource sources.Source, chapterNumber int) (string, error) {
+	if chapterNumber < 1 {
+		return "", errors.New("chapter number must be positive")
+	}
+
+	entries, err := r.GetTableOfContents(novel.ID)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if entry.Number == chapterNumber {
+			return entry.URL, nil
+		}
+	}
+
+	if provider, ok := sources.GetChapterUrlPatternProvider(novelSource); ok {
+		if pattern := provider.GetChapterUrlPattern(novel.URL); pattern != "" {
+			return sources.ChapterUrl(pattern, chapterNumber), nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: chapter %d of novel %s", ErrUnknownChapterURL, chapterNumber, novel.ID)
+}
+
+// numberedChapterUrl returns the URL of a chapter asked for by number. A URL given with the number is kept when the URL
+// of the chapter is unknown, and must otherwise be that of the chapter.
+func numberedChapterUrl(r repo.Repo, novel *models.Novel, novelSource sources.Source, chapterNumber int, givenUrl string) (string, error) {
+	url, err := chapterUrl(r, novel, novelSource, chapterNumber)
+	if givenUrl == "" {
+		return url, err
+	}
+	if errors.Is(err, ErrUnknownChapterURL) {
+		return givenUrl, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// URLs of the same chapter may differ by their host, e.g. on a mirror, but not by the chapter ID found in them
+	if novelSource.GetChapterId(url) != novelSource.GetChapterId(givenUrl) {
+		return "", fmt.Errorf("%w: chapter %d of novel %s is %s, not %s", ErrChapterURLMismatch, chapterNumber, novel.ID, url, givenUrl)
+	}
+	return givenUrl, nil
+}
+
+// previousSequentialChapter returns the chapter that a chapter translated without a number follows: the translated
+// chapter linking to its URL, or else the last of the chapters translated in a row from the first translated one, so
+// that the gaps left by the chapters translated out of order are filled first
+func (s *translationService) previousSequentialChapter(novelID, language, chapterURL string) (*models.Chapter, error) {
+	chapters, err := s.repo.GetNovelChapters(novelID, language)
+	if err != nil {
+		return nil, err
+	}
+	if len(chapters) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	previous := chapters[0]
+	for _, chapter := range chapters[1:] {
+		if chapter.Number > previous.Number+1 {
+			break
+		}
+		previous = chapter
+	}
+
+	if chapterURL != "" {
+		for _, chapter := range chapters {
+			if chapter.NextChapterURL == chapterURL {
+				previous = chapter
+				break
+			}
+		}
+	}
+
+	// The chapters are listed without their content, which gives the translation its continuity
+	return s.repo.GetChapterByID(novelID, previous.ID)
+}
+
+// UpdateTableOfContents scrapes the list of all the chapters of a novel from its source. The chapters not translated
+// yet are listed with the translated ones.
+func (s *translationService) UpdateTableOfContents(request *models.TableOfContentsRequest) ([]*models.TableOfContentsEntry, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	if request.NovelID == "" {
+		return nil, errors.New("novel ID cannot be empty")
+	}
+
+	success := utils.Mutex.TryLock("tableOfContents"+request.NovelID, 5*time.Millisecond)
+	if !success {
+		return nil, errors.New("another request is in progress")
+	}
+	defer func() {
+		utils.Mutex.Unlock("tableOfContents" + request.NovelID)
+	}()
+
+	// Get the novel by ID to ensure it exists
+	novel, err := s.repo.GetNovelByID(request.NovelID)
+	if err != nil {
+		return nil, err
+	}
+
+	novelSource, err := sources.GetSource(novel.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := sources.GetTableOfContentsProvider(novelSource)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateTableOfContents(novel, provider, request.HTMLContent)
+}
+
+// listChapters updates in the background the table of contents of a novel whose page was just scraped, reusing the
+// page when it is where the table of contents starts. Failures are only logged as the novel details do not depend on
+// it, and nothing is done while another update of the table of contents is in progress.
+func (s *translationService) listChapters(novel *models.Novel, novelSource sources.Source, novelPage string) {
+	provider, err := sources.GetTableOfContentsProvider(novelSource)
+	if err != nil {
+		return
+	}
+
+	var firstPage *string
+	if provider.GetTableOfContentsUrl(novel.URL) == novel.URL {
+		firstPage = &novelPage
+	}
+
+	go func() {
+		success := utils.Mutex.TryLock("tableOfContents"+novel.ID, 5*time.Millisecond)
+		if !success {
+			log.Printf("Not updating table of contents of novel %s: another update is in progress", novel.ID)
+			return
+		}
+		defer func() {
+			utils.Mutex.Unlock("tableOfContents" + novel.ID)
+		}()
+
+		if _, err := s.updateTableOfContents(novel, provider, firstPage); err != nil {
+			log.Printf("Failed to update table of contents of novel %s: %v", novel.ID, err)
+		}
+	}()
+}
+
+// updateTableOfContents scrapes every page of the table of contents, starting with firstPage when it is given, and
+// replaces the one saved for the novel
+func (s *translationService) updateTableOfContents(novel *models.Novel, provider sources.TableOfContentsProvider, firstPage *string) ([]*models.TableOfContentsEntry, error) {
+	pageUrl := provider.GetTableOfContentsUrl(novel.URL)
+	if pageUrl == "" {
+		return nil, fmt.Errorf("no table of contents found for %s", novel.URL)
+	}
+
+	var entries []*models.TableOfContentsEntry
+	listed := map[string]bool{}
+	scrapedPages := map[string]bool{}
+	for page := 0; pageUrl != "" && !scrapedPages[pageUrl] && page < maxTableOfContentsPages; page++ {
+		scrapedPages[pageUrl] = true
+
+		var pageContent string
+		if page == 0 && firstPage != nil {
+			pageContent = *firstPage
+		} else {
+			webpageContent, err := webscraper.GetScraperService().ScrapeWebPage(pageUrl)
+			if err != nil {
+				return nil, err
+			}
+			pageContent = webpageContent
+		}
+
+		pageEntries, nextPageUrl, err := provider.GetTableOfContents(pageContent, pageUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		// Chapters linked twice, e.g. from a "latest chapters" block, keep their first position
+		for _, entry := range pageEntries {
+			if !listed[entry.URL] {
+				listed[entry.URL] = true
+				entries = append(entries, entry)
+			}
+		}
+		pageUrl = nextPageUrl
+	}
+
+	// An empty list is more likely a change of the page layout than a novel without chapters
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no chapters found in the table of contents of %s", novel.URL)
+	}
+
+	// Chapters are numbered by their position unless the source knows their number. A number listed twice keeps its
+	// first chapter.
+	now := time.Now().Unix()
+	numbered := map[int]bool{}
+	numberedEntries := make([]*models.TableOfContentsEntry, 0, len(entries))
+	lastNumber := 0
+	for i, entry := range entries {
+		if entry.Number == 0 {
+			entry.Number = i + 1
+		}
+		if numbered[entry.Number] {
+			continue
+		}
+		numbered[entry.Number] = true
+
+		entry.NovelID = novel.ID
+		entry.DateScraped = now
+		numberedEntries = append(numberedEntries, entry)
+		lastNumber = max(lastNumber, entry.Number)
+	}
+	entries = numberedEntries
+
+	if err := s.repo.SaveTableOfContents(novel.ID, entries); err != nil {
+		return nil, err
+	}
+
+	// The novel is read again as it may have been updated while the table of contents was scraped
+	novel, err := s.repo.GetNovelByID(novel.ID)
+	if err != nil {
+		return nil, err
+	}
+	if lastNumber > novel.ChaptersCount {
+		novel.ChaptersCount = lastNumber
+		if err = s.repo.UpdateNovel(novel); err != nil {
+			log.Printf("Failed to update chapters count: %v", err)
+		}
+	}
+
+	return entries, nil
+}
+
+// withUntranslatedChapters completes the translated chapters with those that are not translated in the language,
+// which have no content: the chapters of the table of contents of the novel, and the gaps left by chapters translated
+// out of order. Chapters are matched by URL or ID, as their numbers may differ from those of the table of contents.
+func withUntranslatedChapters(novel *models.Novel, chapters []*models.Chapter, entries []*models.TableOfContentsEntry, language string) []*models.Chapter {
+	numbered := map[int]bool{}
+	translated := map[string]bool{}
+	lastNumber := 0
+	for _, chapter := range chapters {
+		numbered[chapter.Number] = true
+		lastNumber = max(lastNumber, chapter.Number)
+		translated[chapter.ID] = true
+		if chapter.URL != "" {
+			translated[chapter.URL] = true
+		}
+	}
+
+	// The untranslated chapters get the URL and the ID they will have once translated, when they are known
+	novelSource, _ := sources.GetSource(novel.Source)
+	untranslatedChapter := func(number int, url, originalTitle string) *models.Chapter {
+		chapter := &models.Chapter{
+			NovelID:       novel.ID,
+			Number:        number,
+			OriginalTitle: originalTitle,
+			URL:           url,
+			Language:      language,
+		}
+		if novelSource != nil && url != "" {
+			chapter.ID = models.ChapterIDForLanguage(novelSource.GetChapterId(url), language)
+		}
+		return chapter
+	}
+	isTranslated := func(chapter *models.Chapter) bool {
+		return (chapter.URL != "" && translated[chapter.URL]) || (chapter.ID != "" && translated[chapter.ID])
+	}
+
+	for _, entry := range entries {
+		numbered[entry.Number] = true
+		if chapter := untranslatedChapter(entry.Number, entry.URL, entry.OriginalTitle); !isTranslated(chapter) {
+			chapters = append(chapters, chapter)
+		}
+	}
+
+	var pattern string
+	if provider, ok := sources.GetChapterUrlPatternProvider(novelSource); ok {
+		pattern = provider.GetChapterUrlPattern(novel.URL)
+	}
+	for number := 1; number < lastNumber; number++ {
+		if numbered[number] {
+			continue
+		}
+
+		url := ""
+		if pattern != "" {
+			url = sources.ChapterUrl(pattern, number)
+		}
+		if chapter := untranslatedChapter(number, url, ""); !isTranslated(chapter) {
+			chapters = append(chapters, chapter)
+		}
+	}
+
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].Number < chapters[j].Number
+	})
+	return chapters
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"log"
 	"time"
 
@@ -20,23 +22,44 @@ type TranslationService interface {
 	ExtractNovelDetails(ctx context.Context, request *models.NovelExtractionRequest) (*models.Novel, error)
 	TranslateChapter(ctx context.Context, request *models.ChapterTranslationRequest) (*models.Chapter, error)
 	TranslateFirstChapter(ctx context.Context, request *models.ChapterTranslationRequest) (*models.Chapter, error)
+	RetranslateChapter(ctx context.Context, request *models.ChapterRetranslationRequest) (*models.Chapter, error)
 	RefreshNovel(ctx context.Context, request *models.NovelRefreshRequest) (*models.Novel, error)
+	UpdateTableOfContents(request *models.TableOfContentsRequest) ([]*models.TableOfContentsEntry, error)
+	GetPromptTemplates() []*models.PromptTemplate
 }
 
+// ErrInvalidNovelURL is returned when adding a novel from a URL that is not a novel page of its source
+var ErrInvalidNovelURL = errors.New("not a novel URL")
+
+// previousChapterTailLength is how much of the previous chapter, in characters, is passed to the LLM for continuity
+const previousChapterTailLength = 1500
+
 type translationService struct {
-	repo repo.Repo
-}
+	repo     repo.Repo
+	glossary GlossaryService
+	budget   BudgetService
+	styles   StyleService
+	quality  *qualityGate
 
-var translationServiceInstance TranslationService
+	// chunkTokenBudget is the estimated size in tokens above which a chapter is translated in several parts
+	chunkTokenBudget int
 
-func init() {
-	translationServiceInstance = NewTranslationService(repo.GetRepo())
+	// chunkParallelism is how many parts of a long chapter are translated at the same time
+	chunkParallelism int
 }
 
+var translationServiceInstance TranslationService
+
 // NewTranslationService creates a new novel service
 func NewTranslationService(r repo.Repo) TranslationService {
 	return &translationService{
-		repo: r,
+		repo:             r,
+		glossary:         NewGlossaryService(r),
+		budget:           NewBudgetService(r),
+		styles:           NewStyleService(r),
+		quality:          newQualityGate(),
+		chunkTokenBudget: utils.GetEnvPositiveInt("CHAPTER_CHUNK_TOKEN_BUDGET", 8000),
+		chunkParallelism: utils.GetEnvInt("CHAPTER_CHUNK_PARALLELISM", 1),
 	}
 }
 
@@ -52,8 +75,42 @@ func (s *translationService) ExtractNovelDetails(ctx context.Context, request *m
 	if request.URL == "" {
 		return nil, errors.New("URL cannot be empty")
 	}
+	// The source is detected from the URL when the request does not give it
+	resolution, err := sources.ResolveSource(request.URL)
 	if request.Source == "" {
-		return nil, errors.New("source cannot be empty")
+		if err != nil {
+			return nil, err
+		}
+		request.Source = resolution.Source
+	}
+
+	// Chapter pages of the source cannot be added as novels
+	if resolution != nil && resolution.Source == request.Source && resolution.PageType == models.PageTypeChapter {
+		return nil, fmt.Errorf("%w: %s is a chapter page", ErrInvalidNovelURL, request.URL)
+	}
+
+	source, err := sources.GetSource(request.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	novelId := source.GetNovelId(request.URL)
+	if novelId == "" {
+		return nil, fmt.Errorf("%w: no novel ID found in %s", ErrInvalidNovelURL, request.URL)
+	}
+
+	llmClient, err := llm.GetClient(request.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	// An unknown style preset is refused before anything is scraped or translated
+	if request.Style != "" {
+		preset, err := s.styles.GetStylePreset(request.Style)
+		if err != nil {
+			return nil, err
+		}
+		request.Style = preset.Name
 	}
 
 	success := utils.Mutex.TryLock("extractNovelDetails"+request.URL, 5*time.Millisecond)
@@ -64,7 +121,6 @@ func (s *translationService) ExtractNovelDetails(ctx context.Context, request *m
 		utils.Mutex.Unlock("extractNovelDetails" + request.URL)
 	}()
 
-	novelId := sources.GetSource(request.Source).GetNovelId(request.URL)
 	existingNovel, err := s.repo.GetNovelByID(novelId)
 	if existingNovel != nil && existingNovel.ID != "" {
 		return existingNovel, nil
@@ -80,13 +136,19 @@ func (s *translationService) ExtractNovelDetails(ctx context.Context, request *m
 	}
 
 	// Get cover image URL
-	coverUrl, err := sources.GetSource(request.Source).GetNovelCoverImageUrl(*request.HTMLContent)
+	coverUrl, err := source.GetNovelCoverImageUrl(*request.HTMLContent)
 	if err != nil {
 		return nil, err
 	}
 
+	// Check the spending budgets before calling the LLM
+	if err = s.budget.CheckBudget(novelId); err != nil {
+		return nil, err
+	}
+
 	// Translate the novel details
-	novelDetails, err := llm.GetClaude().TranslateNovelDetails(ctx, *request.HTMLContent)
+	targetLanguage := models.NormalizeLanguage(request.TargetLanguage)
+	novelDetails, err := llmClient.TranslateNovelDetails(llm.WithUsageLabels(llm.WithCacheBypass(ctx, request.NoCache), novelId, 0, llm.OperationNovelDetails), novelLanguage(&models.Novel{TargetLanguage: targetLanguage}), *request.HTMLContent)
 	if err != nil {
 		return nil, err
 	}
@@ -107,9 +169,19 @@ func (s *translationService) ExtractNovelDetails(ctx context.Context, request *m
 		LastReadTimestamp: time.Now().Unix(),
 		LastUpdated:       time.Now().Unix(),
 		DateAdded:         time.Now().Unix(),
+		TargetLanguage:    targetLanguage,
+		Style:             request.Style,
+	}
+
+	createdNovel, err := s.repo.CreateNovel(newNovel)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.CreateNovel(newNovel)
+	// List all the chapters of the novel when the source can
+	s.listChapters(createdNovel, source, *request.HTMLContent)
+
+	return createdNovel, nil
 }
 
 func (s *translationService) TranslateFirstChapter(ctx context.Context, request *models.ChapterTranslationRequest) (*models.Chapter, error) {
@@ -120,6 +192,12 @@ func (s *translationService) TranslateFirstChapter(ctx context.Context, request
 		return nil, errors.New("novel ID cannot be empty")
 	}
 
+	provider := llm.ProviderOrDefault(request.Provider)
+	llmClient, err := llm.GetClient(provider)
+	if err != nil {
+		return nil, err
+	}
+
 	success := utils.Mutex.TryLock("translateChapter"+request.NovelID, 5*time.Millisecond)
 	if !success {
 		return nil, errors.New("another request is in progress")
@@ -128,16 +206,34 @@ func (s *translationService) TranslateFirstChapter(ctx context.Context, request
 		utils.Mutex.Unlock("translateChapter" + request.NovelID)
 	}()
 
-	existingChapters, err := s.repo.GetNovelChapters(request.NovelID)
-	if len(existingChapters) > 0 {
-		return existingChapters[0], nil
-	}
-
 	// Get the novel by ID to ensure it exists
 	novel, err := s.repo.GetNovelByID(request.NovelID)
 	if err != nil {
 		return nil, err
 	}
+	language := resolveLanguage(novel, request.Language)
+	style, err := resolveStyle(s.styles, novel, "")
+	if err != nil {
+		return nil, err
+	}
+
+	novelSource, err := sources.GetSource(novel.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first chapter may have been translated already, or its page translated under another number
+	existingChapter, err := s.repo.GetChapterByNumber(novel.ID, 1, language)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if existingChapter != nil {
+		return existingChapter, nil
+	}
+	existingChapter, err = s.repo.GetChapterByURL(request.ChapterURL, language)
+	if existingChapter != nil && existingChapter.ID != "" {
+		return existingChapter, nil
+	}
 
 	// Scrape the chapter content
 	if request.HTMLContent == nil {
@@ -149,14 +245,24 @@ func (s *translationService) TranslateFirstChapter(ctx context.Context, request
 	}
 
 	// Get the next chapter URL
-	nextChapterURL, err := sources.GetSource(novel.Source).GetNextChapterUrl(*request.HTMLContent, request.ChapterURL)
+	nextChapterURL, err := novelSource.GetNextChapterUrl(*request.HTMLContent, request.ChapterURL)
 	if err != nil {
 		log.Printf("Failed to get next chapter url: %v", err)
 		return nil, err
 	}
 
+	// Only the chapter itself is translated when the source knows where it is in the page
+	pageContent := *request.HTMLContent
+	var extracted bool
+	request.HTMLContent, extracted = extractChapter(novelSource, request.HTMLContent)
+
+	// Check the spending budgets before calling the LLM
+	if err = s.budget.CheckBudget(novel.ID); err != nil {
+		return nil, err
+	}
+
 	// Translate the chapter content
-	translatedContent, err := llm.GetClaude().TranslateNovelChapter(ctx, novel.Genres, *request.HTMLContent)
+	translatedContent, quality, err := s.translateWithQualityGate(llm.WithUsageLabels(llm.WithCacheBypass(ctx, request.NoCache), novel.ID, 1, llm.OperationChapter), novel.ID, provider, llmClient, s.buildChapterContext(novel, language, style, 1, nil), *request.HTMLContent, extracted)
 	if err != nil {
 		log.Printf("Failed to translate chapter: %v", err)
 		return nil, err
@@ -171,7 +277,7 @@ func (s *translationService) TranslateFirstChapter(ctx context.Context, request
 
 	// Create a new chapter entry
 	chapter := &models.Chapter{
-		ID:             sources.GetSource(novel.Source).GetChapterId(request.ChapterURL),
+		ID:             models.ChapterIDForLanguage(novelSource.GetChapterId(request.ChapterURL), language),
 		NovelID:        novel.ID,
 		Number:         1,
 		Title:          translatedContent.TranslatedChapterTitle,
@@ -181,9 +287,47 @@ func (s *translationService) TranslateFirstChapter(ctx context.Context, request
 		WordCount:      utils.CountWords(translatedContent.TranslatedChapterContents),
 		URL:            request.ChapterURL,
 		NextChapterURL: nextChapterURL,
+		Language:       language,
+		Style:          style.Name,
+		PromptVersion:  llm.PromptVersion(llm.OperationChapter),
+	}
+	chapter.Provider, chapter.Attempts = translationProvenance(provider, translatedContent)
+	chapter.QualityScore, chapter.QualityFlags = &quality.score, quality.flags
+	chapter.Version = 1
+
+	createdChapter, err := s.repo.CreateChapter(chapter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = recordChapterVersion(s.repo, chapter, ""); err != nil {
+		log.Printf("Failed to record chapter version: %v", err)
 	}
 
-	return s.repo.CreateChapter(chapter)
+	// Keep the original so that the chapter can be retranslated without scraping it again
+	if err = saveChapterSource(s.repo, chapter, pageContent, *request.HTMLContent, extracted); err != nil {
+		log.Printf("Failed to save chapter source: %v", err)
+	}
+
+	// Pair the paragraphs of the translation with those of the original for bilingual reading
+	if _, err = saveChapterAlignment(s.repo, chapter, utils.HTMLToText(*request.HTMLContent)); err != nil {
+		log.Printf("Failed to align chapter: %v", err)
+	}
+
+	// The glossary and the story summary are kept in the language of the novel only
+	if language == novelLanguage(novel) {
+		// Keep the glossary growing with the names and terms introduced by this chapter
+		if err = s.glossary.MergeExtractedTerms(novel.ID, chapter.Number, translatedContent.NewGlossaryTerms); err != nil {
+			log.Printf("Failed to merge glossary terms: %v", err)
+		}
+
+		// Roll the story summary forward so the next chapter knows what happened in this one
+		if err = s.updateStoryContext(novel.ID, chapter.Number, translatedContent.UpdatedStorySummary); err != nil {
+			log.Printf("Failed to update story context: %v", err)
+		}
+	}
+
+	return createdChapter, nil
 }
 
 func (s *translationService) TranslateChapter(ctx context.Context, request *models.ChapterTranslationRequest) (*models.Chapter, error) {
@@ -194,6 +338,12 @@ func (s *translationService) TranslateChapter(ctx context.Context, request *mode
 		return nil, errors.New("novel ID cannot be empty")
 	}
 
+	provider := llm.ProviderOrDefault(request.Provider)
+	llmClient, err := llm.GetClient(provider)
+	if err != nil {
+		return nil, err
+	}
+
 	success := utils.Mutex.TryLock("translateChapter"+request.NovelID, 5*time.Millisecond)
 	if !success {
 		return nil, errors.New("another request is in progress")
@@ -202,24 +352,72 @@ func (s *translationService) TranslateChapter(ctx context.Context, request *mode
 		utils.Mutex.Unlock("translateChapter" + request.NovelID)
 	}()
 
-	existingChapter, err := s.repo.GetChapterByURL(request.ChapterURL)
-	if existingChapter != nil && existingChapter.ID != "" {
-		return existingChapter, nil
-	}
-
 	// Get the novel by ID to ensure it exists
 	novel, err := s.repo.GetNovelByID(request.NovelID)
 	if err != nil {
 		return nil, err
 	}
+	language := resolveLanguage(novel, request.Language)
+	style, err := resolveStyle(s.styles, novel, "")
+	if err != nil {
+		return nil, err
+	}
 
-	lastChapter, err := s.repo.GetLastChapter(novel.ID)
+	novelSource, err := sources.GetSource(novel.Source)
 	if err != nil {
 		return nil, err
 	}
 
+	// A chapter asked for by number is found in the table of contents or from the chapter URL pattern of the source,
+	// which must agree with the URL given with the number. Other chapters follow the chapter translated before them,
+	// filling first the gaps left by the chapters translated out of order.
+	var chapterNumber int
+	var previousChapter *models.Chapter
+	if request.ChapterNumber > 0 {
+		chapterNumber = request.ChapterNumber
+		request.ChapterURL, err = numberedChapterUrl(s.repo, novel, novelSource, chapterNumber, request.ChapterURL)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		previousChapter, err = s.previousSequentialChapter(novel.ID, language, request.ChapterURL)
+		if err != nil {
+			return nil, err
+		}
+		chapterNumber = previousChapter.Number + 1
+		if request.ChapterURL == "" {
+			request.ChapterURL = previousChapter.NextChapterURL
+		}
+		if request.ChapterURL == "" {
+			return nil, fmt.Errorf("%w: chapter %d of novel %s is the latest one", ErrUnknownChapterURL, previousChapter.Number, novel.ID)
+		}
+	}
+
+	existingChapter, err := s.repo.GetChapterByURL(request.ChapterURL, language)
+	if existingChapter != nil && existingChapter.ID != "" {
+		return existingChapter, nil
+	}
+
+	if request.ChapterNumber > 0 {
+		existingChapter, err = s.repo.GetChapterByNumber(novel.ID, chapterNumber, language)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if existingChapter != nil {
+			return existingChapter, nil
+		}
+
+		// The previous chapter gives the translation its continuity, when it was translated
+		if chapterNumber > 1 {
+			previousChapter, err = s.repo.GetChapterByNumber(novel.ID, chapterNumber-1, language)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			}
+		}
+	}
+
 	if request.HTMLContent == nil {
-		chapterContent, err := webscraper.GetScraperService().ScrapeWebPage(lastChapter.NextChapterURL)
+		chapterContent, err := webscraper.GetScraperService().ScrapeWebPage(request.ChapterURL)
 		if err != nil {
 			return nil, err
 		}
@@ -227,13 +425,23 @@ func (s *translationService) TranslateChapter(ctx context.Context, request *mode
 	}
 
 	// Get the next chapter URL
-	nextChapterUrl, err := sources.GetSource(novel.Source).GetNextChapterUrl(*request.HTMLContent, request.ChapterURL)
+	nextChapterUrl, err := novelSource.GetNextChapterUrl(*request.HTMLContent, request.ChapterURL)
 	if err != nil {
 		return nil, err
 	}
 
+	// Only the chapter itself is translated when the source knows where it is in the page
+	pageContent := *request.HTMLContent
+	var extracted bool
+	request.HTMLContent, extracted = extractChapter(novelSource, request.HTMLContent)
+
+	// Check the spending budgets before calling the LLM
+	if err = s.budget.CheckBudget(novel.ID); err != nil {
+		return nil, err
+	}
+
 	// Translate the chapter content
-	translatedContent, err := llm.GetClaude().TranslateNovelChapter(ctx, novel.Genres, *request.HTMLContent)
+	translatedContent, quality, err := s.translateWithQualityGate(llm.WithUsageLabels(llm.WithCacheBypass(ctx, request.NoCache), novel.ID, chapterNumber, llm.OperationChapter), novel.ID, provider, llmClient, s.buildChapterContext(novel, language, style, chapterNumber, previousChapter), *request.HTMLContent, extracted)
 	if err != nil {
 		return nil, err
 	}
@@ -247,9 +455,9 @@ func (s *translationService) TranslateChapter(ctx context.Context, request *mode
 
 	// Create a new chapter entry
 	chapter := &models.Chapter{
-		ID:             sources.GetSource(novel.Source).GetChapterId(request.ChapterURL),
+		ID:             models.ChapterIDForLanguage(novelSource.GetChapterId(request.ChapterURL), language),
 		NovelID:        novel.ID,
-		Number:         lastChapter.Number + 1,
+		Number:         chapterNumber,
 		Title:          translatedContent.TranslatedChapterTitle,
 		OriginalTitle:  translatedContent.OriginalChapterTitle,
 		Content:        translatedContent.TranslatedChapterContents,
@@ -257,9 +465,193 @@ func (s *translationService) TranslateChapter(ctx context.Context, request *mode
 		WordCount:      utils.CountWords(translatedContent.TranslatedChapterContents),
 		URL:            request.ChapterURL,
 		NextChapterURL: nextChapterUrl,
+		Language:       language,
+		Style:          style.Name,
+		PromptVersion:  llm.PromptVersion(llm.OperationChapter),
+	}
+	chapter.Provider, chapter.Attempts = translationProvenance(provider, translatedContent)
+	chapter.QualityScore, chapter.QualityFlags = &quality.score, quality.flags
+	chapter.Version = 1
+
+	createdChapter, err := s.repo.CreateChapter(chapter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = recordChapterVersion(s.repo, chapter, ""); err != nil {
+		log.Printf("Failed to record chapter version: %v", err)
+	}
+
+	// Keep the original so that the chapter can be retranslated without scraping it again
+	if err = saveChapterSource(s.repo, chapter, pageContent, *request.HTMLContent, extracted); err != nil {
+		log.Printf("Failed to save chapter source: %v", err)
+	}
+
+	// Pair the paragraphs of the translation with those of the original for bilingual reading
+	if _, err = saveChapterAlignment(s.repo, chapter, utils.HTMLToText(*request.HTMLContent)); err != nil {
+		log.Printf("Failed to align chapter: %v", err)
+	}
+
+	// The glossary and the story summary are kept in the language of the novel only
+	if language == novelLanguage(novel) {
+		// Keep the glossary growing with the names and terms introduced by this chapter
+		if err = s.glossary.MergeExtractedTerms(novel.ID, chapter.Number, translatedContent.NewGlossaryTerms); err != nil {
+			log.Printf("Failed to merge glossary terms: %v", err)
+		}
+
+		// Roll the story summary forward so the next chapter knows what happened in this one
+		if err = s.updateStoryContext(novel.ID, chapter.Number, translatedContent.UpdatedStorySummary); err != nil {
+			log.Printf("Failed to update story context: %v", err)
+		}
+	}
+
+	return createdChapter, nil
+}
+
+// RetranslateChapter translates an existing chapter again, keeping the translation it replaces as a previous version.
+// The new translation becomes the active version of the chapter.
+func (s *translationService) RetranslateChapter(ctx context.Context, request *models.ChapterRetranslationRequest) (*models.Chapter, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	if request.NovelID == "" {
+		return nil, errors.New("novel ID cannot be empty")
+	}
+	if request.ChapterID == "" {
+		return nil, errors.New("chapter ID cannot be empty")
 	}
 
-	return s.repo.CreateChapter(chapter)
+	provider := llm.ProviderOrDefault(request.Provider)
+	llmClient, err := llm.GetClient(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	success := utils.Mutex.TryLock("translateChapter"+request.NovelID, 5*time.Millisecond)
+	if !success {
+		return nil, errors.New("another request is in progress")
+	}
+	defer func() {
+		utils.Mutex.Unlock("translateChapter" + request.NovelID)
+	}()
+
+	novel, err := s.repo.GetNovelByID(request.NovelID)
+	if err != nil {
+		return nil, err
+	}
+
+	chapter, err := s.repo.GetChapterByID(novel.ID, request.ChapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	novelSource, err := sources.GetSource(novel.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	style, err := resolveStyle(s.styles, novel, request.Style)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use the page given with the request, or the stored original of the chapter, or scrape the page when the chapter
+	// was translated before the originals were kept
+	source, err := s.repo.GetChapterSource(novel.ID, chapter.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	var pageContent string
+	if request.HTMLContent != nil {
+		pageContent = *request.HTMLContent
+	} else if source == nil {
+		if chapter.URL == "" {
+			return nil, errors.New("chapter has no source URL")
+		}
+		pageContent, err = webscraper.GetScraperService().ScrapeWebPage(chapter.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The stored original is kept when the given page did not change since, otherwise only the chapter itself is
+	// translated when the source knows where it is in the page
+	newSource := source == nil || (request.HTMLContent != nil && pageHash(pageContent) != source.SourceHash)
+	var extracted bool
+	if newSource {
+		request.HTMLContent, extracted = extractChapter(novelSource, &pageContent)
+	} else {
+		chapterContent := utils.TextToHTML(source.OriginalText)
+		request.HTMLContent, extracted = &chapterContent, source.Extracted
+	}
+
+	// The previous chapter gives the translation its continuity, when it was translated
+	var previousChapter *models.Chapter
+	if chapter.Number > 1 {
+		previousChapter, err = s.repo.GetChapterByNumber(novel.ID, chapter.Number-1, chapter.Language)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	// Check the spending budgets before calling the LLM
+	if err = s.budget.CheckBudget(novel.ID); err != nil {
+		return nil, err
+	}
+
+	chapterContext := s.buildChapterContext(novel, chapter.Language, style, chapter.Number, previousChapter)
+
+	// Translate the chapter content, bypassing the cache that would return the translation being replaced
+	llmContext := llm.WithModel(llm.WithUsageLabels(llm.WithCacheBypass(ctx, true), novel.ID, chapter.Number, llm.OperationChapter), request.Model)
+	translatedContent, quality, err := s.translateWithQualityGate(llmContext, novel.ID, provider, llmClient, chapterContext, *request.HTMLContent, extracted)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the translation being replaced when it predates the versions
+	latestVersion, err := s.repo.GetLatestChapterVersionNumber(novel.ID, chapter.ID)
+	if err != nil {
+		return nil, err
+	}
+	if latestVersion == 0 {
+		chapter.Version = 1
+		if err = recordChapterVersion(s.repo, chapter, ""); err != nil {
+			return nil, err
+		}
+		latestVersion = 1
+	}
+
+	chapter.Version = latestVersion + 1
+	chapter.Title = translatedContent.TranslatedChapterTitle
+	chapter.OriginalTitle = translatedContent.OriginalChapterTitle
+	chapter.Content = translatedContent.TranslatedChapterContents
+	chapter.DateTranslated = time.Now().Unix()
+	chapter.WordCount = utils.CountWords(translatedContent.TranslatedChapterContents)
+	chapter.Provider, chapter.Attempts = translationProvenance(provider, translatedContent)
+	chapter.QualityScore, chapter.QualityFlags = &quality.score, quality.flags
+	chapter.Style = style.Name
+	chapter.PromptVersion = llm.PromptVersion(llm.OperationChapter)
+
+	if err = recordChapterVersion(s.repo, chapter, request.Model); err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.UpdateChapter(chapter); err != nil {
+		return nil, err
+	}
+
+	if newSource {
+		if err = saveChapterSource(s.repo, chapter, pageContent, *request.HTMLContent, extracted); err != nil {
+			log.Printf("Failed to save chapter source: %v", err)
+		}
+	}
+
+	if _, err = saveChapterAlignment(s.repo, chapter, utils.HTMLToText(*request.HTMLContent)); err != nil {
+		log.Printf("Failed to align chapter: %v", err)
+	}
+
+	return chapter, nil
 }
 
 func (s *translationService) RefreshNovel(ctx context.Context, request *models.NovelRefreshRequest) (*models.Novel, error) {
@@ -267,6 +659,11 @@ func (s *translationService) RefreshNovel(ctx context.Context, request *models.N
 		return nil, errors.New("novel ID cannot be empty")
 	}
 
+	llmClient, err := llm.GetClient(request.Provider)
+	if err != nil {
+		return nil, err
+	}
+
 	success := utils.Mutex.TryLock("refreshNovel"+request.NovelID, 5*time.Millisecond)
 	if !success {
 		return nil, errors.New("another request is in progress")
@@ -281,6 +678,11 @@ func (s *translationService) RefreshNovel(ctx context.Context, request *models.N
 		return nil, err
 	}
 
+	novelSource, err := sources.GetSource(novel.Source)
+	if err != nil {
+		return nil, err
+	}
+
 	// Scrape the webpage content for the novel
 	if request.HTMLContent == nil {
 		webpageContent, err := webscraper.GetScraperService().ScrapeWebPage(novel.URL)
@@ -291,19 +693,24 @@ func (s *translationService) RefreshNovel(ctx context.Context, request *models.N
 	}
 
 	// Cover image URL
-	coverUrl, err := sources.GetSource(novel.Source).GetNovelCoverImageUrl(*request.HTMLContent)
+	coverUrl, err := novelSource.GetNovelCoverImageUrl(*request.HTMLContent)
 	if err != nil {
 		return nil, err
 	}
 
+	// Check the spending budgets before calling the LLM
+	if err = s.budget.CheckBudget(novel.ID); err != nil {
+		return nil, err
+	}
+
 	// Translate the novel details
-	novelDetails, err := llm.GetClaude().TranslateNovelDetails(ctx, *request.HTMLContent)
+	novelDetails, err := llmClient.TranslateNovelDetails(llm.WithUsageLabels(llm.WithCacheBypass(ctx, request.NoCache), novel.ID, 0, llm.OperationNovelDetails), novelLanguage(novel), *request.HTMLContent)
 	if err != nil {
 		return nil, err
 	}
 
 	// Add the next chapter URL to the last chapter if there are new chapters
-	if err = s.addNextChapterUrlToLastChapter(novel.ID, novel.Source); err != nil {
+	if err = s.addNextChapterUrlToLastChapter(novel.ID, novel.Source, novelLanguage(novel)); err != nil {
 		log.Printf("Failed to add next chapter URL to last chapter: %v\n", err)
 	}
 
@@ -322,11 +729,14 @@ func (s *translationService) RefreshNovel(ctx context.Context, request *models.N
 		return nil, err
 	}
 
+	// List the chapters again, new ones may have been published
+	s.listChapters(novel, novelSource, *request.HTMLContent)
+
 	return s.repo.GetNovelByID(request.NovelID)
 }
 
-func (s *translationService) addNextChapterUrlToLastChapter(novelId, source string) error {
-	lastChapter, err := s.repo.GetLastChapter(novelId)
+func (s *translationService) addNextChapterUrlToLastChapter(novelId, source, language string) error {
+	lastChapter, err := s.repo.GetLastChapter(novelId, language)
 	if err != nil {
 		return err
 	}
@@ -336,7 +746,12 @@ func (s *translationService) addNextChapterUrlToLastChapter(novelId, source stri
 		return err
 	}
 
-	nextChapterUrl, err := sources.GetSource(source).GetNextChapterUrl(webpageContent, lastChapter.URL)
+	novelSource, err := sources.GetSource(source)
+	if err != nil {
+		return err
+	}
+
+	nextChapterUrl, err := novelSource.GetNextChapterUrl(webpageContent, lastChapter.URL)
 	if err != nil {
 		return err
 	}
@@ -344,3 +759,99 @@ func (s *translationService) addNextChapterUrlToLastChapter(novelId, source stri
 	lastChapter.NextChapterURL = nextChapterUrl
 	return s.repo.UpdateChapter(lastChapter)
 }
+
+// GetPromptTemplates lists the prompt templates translations are currently made with
+func (s *translationService) GetPromptTemplates() []*models.PromptTemplate {
+	return llm.GetPromptTemplates()
+}
+
+// buildChapterContext gathers what the LLM needs to know about the novel to translate one of its chapters into the language.
+// previousChapter is the chapter preceding the one being translated, or nil when it is not translated.
+// The glossary holds translations into the language of the novel and is left out for other languages. The story
+// summary is only given when it stops right before the chapter, as it would otherwise skip or spoil chapters.
+func (s *translationService) buildChapterContext(novel *models.Novel, language string, style *models.StylePreset, chapterNumber int, previousChapter *models.Chapter) *models.ChapterContext {
+	chapterContext := &models.ChapterContext{
+		TargetLanguage:    language,
+		NovelGenres:       novel.Genres,
+		StyleInstructions: style.Instructions,
+	}
+
+	if language == novelLanguage(novel) {
+		glossary, err := s.repo.GetGlossaryTerms(novel.ID)
+		if err != nil {
+			log.Printf("Failed to load glossary of novel %s: %v", novel.ID, err)
+		}
+		chapterContext.Glossary = glossary
+	}
+
+	storyContext, err := s.repo.GetStoryContext(novel.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Failed to load story context of novel %s: %v", novel.ID, err)
+	}
+	if storyContext != nil && storyContext.LastChapterNumber == chapterNumber-1 {
+		chapterContext.StorySummary = storyContext.Summary
+	}
+
+	if previousChapter == nil {
+		return chapterContext
+	}
+
+	chapterContext.PreviousChapterTail = utils.TailText(utils.HTMLToText(previousChapter.Content), previousChapterTailLength)
+
+	return chapterContext
+}
+
+// updateStoryContext stores the rolling story summary returned with the translation of a chapter.
+// The summary only moves forward one chapter at a time: summaries of chapters translated out of order are ignored, as
+// they were written without the story of the chapters between.
+func (s *translationService) updateStoryContext(novelID string, chapterNumber int, summary string) error {
+	if summary == "" {
+		return nil
+	}
+
+	storyContext, err := s.repo.GetStoryContext(novelID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	lastChapterNumber := 0
+	if storyContext != nil {
+		lastChapterNumber = storyContext.LastChapterNumber
+	}
+	if chapterNumber != lastChapterNumber+1 {
+		return nil
+	}
+
+	return s.repo.SaveStoryContext(&models.StoryContext{
+		NovelID:           novelID,
+		Summary:           summary,
+		LastChapterNumber: chapterNumber,
+		LastUpdated:       time.Now().Unix(),
+	})
+}
+
+// extractChapter narrows the chapter page down to the chapter title and text when the source knows where they are,
+// and reports whether it did. Otherwise only the navigation and the rest of the page chrome are removed.
+func extractChapter(source sources.Source, chapterContent *string) (*string, bool) {
+	if extractor, ok := source.(sources.ChapterExtractor); ok {
+		content, err := extractor.ExtractChapter(*chapterContent)
+		if err == nil {
+			return &content, true
+		}
+		if !errors.Is(err, sources.ErrChapterNotFound) {
+			log.Printf("Failed to extract the chapter from its page: %v", err)
+		}
+	}
+
+	content := sources.StripPageChrome(*chapterContent)
+	return &content, false
+}
+
+// translationProvenance returns the provider that produced a translated chapter and how many attempts it took.
+// The fallback client and chunked translations report these themselves, any other provider succeeds on its first attempt.
+func translationProvenance(provider string, translatedContent *models.TranslatedChapter) (string, int) {
+	attempts := max(translatedContent.Attempts, 1)
+	if translatedContent.Provider == "" {
+		return provider, attempts
+	}
+	return translatedContent.Provider, attempts
+}
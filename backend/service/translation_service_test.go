@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"backend/models"
+	"backend/provider/llm"
+)
+
+const testNovelURL = "https://www.sjks88.com/xuanhuan/51964.html"
+
+// createTestNovel adds a novel of sjks88, whose chapter URLs follow a pattern, without translating its details
+func createTestNovel(t *testing.T, s *translationService) *models.Novel {
+	t.Helper()
+
+	novel, err := s.repo.CreateNovel(&models.Novel{
+		ID:            "51964",
+		Title:         "Test Novel",
+		OriginalTitle: "测试小说",
+		Source:        "sjks88",
+		URL:           testNovelURL,
+		Status:        "Ongoing",
+		Genres:        []string{},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create the novel: %v", err)
+	}
+	return novel
+}
+
+// translateTestChapter translates a chapter of the test novel with the fake provider from the given page
+func translateTestChapter(s *translationService, novelID string, chapterNumber int, chapterURL string) (*models.Chapter, error) {
+	page := fmt.Sprintf("<html><body><h1>第%d章</h1><p>他走进了山门。</p><p>师父在等他。</p></body></html>", chapterNumber)
+	return s.TranslateChapter(context.Background(), &models.ChapterTranslationRequest{
+		NovelID:       novelID,
+		ChapterNumber: chapterNumber,
+		ChapterURL:    chapterURL,
+		Provider:      llm.ProviderFake,
+		HTMLContent:   &page,
+	})
+}
+
+func TestTranslateChapterOutOfOrder(t *testing.T) {
+	s := newTestTranslationService(t)
+	novel := createTestNovel(t, s)
+
+	first, err := translateTestChapter(s, novel.ID, 1, "")
+	if err != nil {
+		t.Fatalf("Failed to translate chapter 1: %v", err)
+	}
+	if first.Number != 1 || first.URL != "https://www.sjks88.com/xuanhuan/51964/1.html" {
+		t.Errorf("expected chapter 1 from the URL pattern, got chapter %d at %s", first.Number, first.URL)
+	}
+
+	fifth, err := translateTestChapter(s, novel.ID, 5, "")
+	if err != nil {
+		t.Fatalf("Failed to translate chapter 5: %v", err)
+	}
+	if fifth.Number != 5 || fifth.URL != "https://www.sjks88.com/xuanhuan/51964/5.html" {
+		t.Errorf("expected chapter 5 from the URL pattern, got chapter %d at %s", fifth.Number, fifth.URL)
+	}
+
+	// Chapter 5 does not follow the summarized chapters, so it leaves the story summary where it was
+	storyContext, err := s.repo.GetStoryContext(novel.ID)
+	if err != nil {
+		t.Fatalf("Failed to get the story context: %v", err)
+	}
+	if storyContext.LastChapterNumber != 1 {
+		t.Errorf("expected the story summary to stop at chapter 1, got %d", storyContext.LastChapterNumber)
+	}
+
+	// A chapter translated without a number fills the gap after chapter 1
+	next, err := translateTestChapter(s, novel.ID, 0, "")
+	if err != nil {
+		t.Fatalf("Failed to translate the next chapter: %v", err)
+	}
+	if next.Number != 2 || next.URL != "https://www.sjks88.com/xuanhuan/51964/2.html" {
+		t.Errorf("expected the next chapter to be chapter 2, got chapter %d at %s", next.Number, next.URL)
+	}
+
+	storyContext, err = s.repo.GetStoryContext(novel.ID)
+	if err != nil {
+		t.Fatalf("Failed to get the story context: %v", err)
+	}
+	if storyContext.LastChapterNumber != 2 {
+		t.Errorf("expected the story summary to advance to chapter 2, got %d", storyContext.LastChapterNumber)
+	}
+
+	// A chapter already translated is returned as it is
+	again, err := translateTestChapter(s, novel.ID, 5, "")
+	if err != nil {
+		t.Fatalf("Failed to translate chapter 5 again: %v", err)
+	}
+	if again.ID != fifth.ID || again.Content != fifth.Content {
+		t.Errorf("expected the existing chapter 5, got chapter %s", again.ID)
+	}
+}
+
+func TestTranslateChapterURLMismatch(t *testing.T) {
+	s := newTestTranslationService(t)
+	novel := createTestNovel(t, s)
+
+	_, err := translateTestChapter(s, novel.ID, 3, "https://www.sjks88.com/xuanhuan/51964/4.html")
+	if !errors.Is(err, ErrChapterURLMismatch) {
+		t.Fatalf("expected ErrChapterURLMismatch, got %v", err)
+	}
+
+	// The same chapter on a mirror is accepted
+	chapter, err := translateTestChapter(s, novel.ID, 3, "https://sjks88.com/xuanhuan/51964/3.html")
+	if err != nil {
+		t.Fatalf("Failed to translate chapter 3 from a mirror: %v", err)
+	}
+	if chapter.Number != 3 {
+		t.Errorf("expected chapter 3, got chapter %d", chapter.Number)
+	}
+}
+
+func TestTranslateChapterWithoutPreviousChapter(t *testing.T) {
+	s := newTestTranslationService(t)
+	novel := createTestNovel(t, s)
+
+	if _, err := translateTestChapter(s, novel.ID, 0, ""); err == nil {
+		t.Fatal("expected an error when no chapter was translated before")
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"unicode"
+
+	"golang.org/x/net/html"
 )
 
 // GetDBPath returns the path to the SQLite database file
@@ -77,3 +80,204 @@ func RemoveDuplicatesFromSlice(array []string) []string {
 
 	return result
 }
+
+// HTMLToText strips the tags from an HTML fragment and returns its text with one block (paragraph, heading, line) per line.
+// Scripts and styles are dropped.
+func HTMLToText(content string) string {
+	var builder strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	skipDepth := 0
+
+	for {
+		switch tokenType := tokenizer.Next(); tokenType {
+		case html.ErrorToken:
+			// Drop the blank lines left behind by nested or empty blocks
+			var lines []string
+			for _, line := range strings.Split(builder.String(), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, line)
+				}
+			}
+			return strings.Join(lines, "\n")
+		case html.TextToken:
+			if skipDepth == 0 {
+				builder.Write(tokenizer.Text())
+			}
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "script", "style", "noscript":
+				if tokenType == html.StartTagToken {
+					skipDepth++
+				} else if tokenType == html.EndTagToken && skipDepth > 0 {
+					skipDepth--
+				}
+			case "p", "br", "div", "li", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote":
+				builder.WriteString("\n")
+			}
+		}
+	}
+}
+
+// TextToHTML turns a text with one paragraph per line into HTML paragraphs
+func TextToHTML(text string) string {
+	var builder strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			builder.WriteString("<p>" + html.EscapeString(line) + "</p>\n")
+		}
+	}
+	return builder.String()
+}
+
+// TailText returns at most maxRunes runes from the end of text.
+// The partial first line is dropped when that keeps at least half of the tail.
+func TailText(text string, maxRunes int) string {
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+
+	tail := string(runes[len(runes)-maxRunes:])
+	if idx := strings.Index(tail, "\n"); idx != -1 && idx < len(tail)/2 {
+		return tail[idx+1:]
+	}
+
+	return tail
+}
+
+// EstimateTokens roughly estimates the number of LLM tokens in a text.
+// CJK characters count as one token each, everything else as one token per four characters.
+func EstimateTokens(text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		if IsCJK(r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// IsCJK reports whether the rune is a Chinese, Japanese or Korean character
+func IsCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// SplitIntoChunks splits a text with one paragraph per line into chunks of whole paragraphs,
+// each estimated under maxTokens. Paragraphs that are too long on their own are split by characters.
+func SplitIntoChunks(text string, maxTokens int) []string {
+	var chunks []string
+	var current []string
+	currentTokens := 0
+
+	flush := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, strings.Join(current, "\n"))
+			current = nil
+			currentTokens = 0
+		}
+	}
+
+	for _, paragraph := range strings.Split(text, "\n") {
+		tokens := EstimateTokens(paragraph)
+		if tokens > maxTokens {
+			flush()
+			chunks = append(chunks, splitParagraph(paragraph, maxTokens)...)
+			continue
+		}
+
+		if currentTokens+tokens > maxTokens {
+			flush()
+		}
+		current = append(current, paragraph)
+		currentTokens += tokens
+	}
+	flush()
+
+	return chunks
+}
+
+// splitParagraph splits a single paragraph into pieces estimated under maxTokens
+func splitParagraph(paragraph string, maxTokens int) []string {
+	var pieces []string
+	var builder strings.Builder
+	cjk, other := 0, 0
+
+	for _, r := range paragraph {
+		builder.WriteRune(r)
+		if IsCJK(r) {
+			cjk++
+		} else {
+			other++
+		}
+
+		if cjk+(other+3)/4 >= maxTokens {
+			pieces = append(pieces, builder.String())
+			builder.Reset()
+			cjk, other = 0, 0
+		}
+	}
+	if builder.Len() > 0 {
+		pieces = append(pieces, builder.String())
+	}
+
+	return pieces
+}
+
+// GetEnvInt returns the integer value of an environment variable, or defaultValue if it is unset or invalid
+func GetEnvInt(name string, defaultValue int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: Invalid value %q for %s, using %d", value, name, defaultValue)
+		return defaultValue
+	}
+
+	return intValue
+}
+
+// GetEnvPositiveInt returns the integer value of an environment variable, or defaultValue if it is unset, invalid,
+// zero or negative
+func GetEnvPositiveInt(name string, defaultValue int) int {
+	intValue := GetEnvInt(name, defaultValue)
+	if intValue <= 0 {
+		log.Printf("Warning: Invalid value %d for %s, using %d", intValue, name, defaultValue)
+		return defaultValue
+	}
+
+	return intValue
+}
+
+// GetEnvNonNegativeInt returns the integer value of an environment variable, or defaultValue if it is unset,
+// invalid or negative
+func GetEnvNonNegativeInt(name string, defaultValue int) int {
+	intValue := GetEnvInt(name, defaultValue)
+	if intValue < 0 {
+		log.Printf("Warning: Invalid value %d for %s, using %d", intValue, name, defaultValue)
+		return defaultValue
+	}
+
+	return intValue
+}
+
+// GetEnvFloat returns the float value of an environment variable, or defaultValue if it is unset or invalid
+func GetEnvFloat(name string, defaultValue float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: Invalid value %q for %s, using %v", value, name, defaultValue)
+		return defaultValue
+	}
+
+	return floatValue
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitIntoChunksKeepsWholeParagraphs(t *testing.T) {
+	paragraphs := []string{"第一段文字", "第二段文字", "第三段文字", "第四段文字"}
+	chunks := SplitIntoChunks(strings.Join(paragraphs, "\n"), 10)
+
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d: %q", len(chunks), chunks)
+	}
+	if chunks[0] != "第一段文字\n第二段文字" || chunks[1] != "第三段文字\n第四段文字" {
+		t.Errorf("chunks do not hold whole paragraphs in order: %q", chunks)
+	}
+	for _, chunk := range chunks {
+		if tokens := paragraphTokens(chunk); tokens > 10 {
+			t.Errorf("chunk %q is estimated at %d tokens, over the budget", chunk, tokens)
+		}
+	}
+}
+
+func TestSplitIntoChunksSplitsLongParagraphs(t *testing.T) {
+	paragraph := strings.Repeat("字", 25)
+	chunks := SplitIntoChunks("短\n"+paragraph+"\n短", 10)
+
+	if len(chunks) != 5 {
+		t.Fatalf("expected 5 chunks, got %d: %q", len(chunks), chunks)
+	}
+	if strings.Join(chunks[1:4], "") != paragraph {
+		t.Errorf("the long paragraph was not split in order: %q", chunks[1:4])
+	}
+	for _, chunk := range chunks {
+		if tokens := EstimateTokens(chunk); tokens > 10 {
+			t.Errorf("chunk %q is estimated at %d tokens, over the budget", chunk, tokens)
+		}
+	}
+}
+
+func TestSplitIntoChunksUnderBudget(t *testing.T) {
+	chunks := SplitIntoChunks("one\ntwo", 100)
+	if len(chunks) != 1 || chunks[0] != "one\ntwo" {
+		t.Errorf("expected a single chunk, got %q", chunks)
+	}
+}
+
+func TestGetEnvPositiveInt(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{"", 8},
+		{"12", 12},
+		{"0", 8},
+		{"-3", 8},
+		{"many", 8},
+	}
+
+	for _, test := range tests {
+		t.Setenv("TEST_POSITIVE_INT", test.value)
+		if actual := GetEnvPositiveInt("TEST_POSITIVE_INT", 8); actual != test.expected {
+			t.Errorf("GetEnvPositiveInt with %q: expected %d, got %d", test.value, test.expected, actual)
+		}
+	}
+}
+
+func TestGetEnvNonNegativeInt(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{"", 1},
+		{"0", 0},
+		{"3", 3},
+		{"-1", 1},
+	}
+
+	for _, test := range tests {
+		t.Setenv("TEST_NON_NEGATIVE_INT", test.value)
+		if actual := GetEnvNonNegativeInt("TEST_NON_NEGATIVE_INT", 1); actual != test.expected {
+			t.Errorf("GetEnvNonNegativeInt with %q: expected %d, got %d", test.value, test.expected, actual)
+		}
+	}
+}
+
+// paragraphTokens estimates the tokens of the paragraphs of a chunk, without the line breaks joining them
+func paragraphTokens(chunk string) int {
+	tokens := 0
+	for _, paragraph := range strings.Split(chunk, "\n") {
+		tokens += EstimateTokens(paragraph)
+	}
+	return tokens
+}